@@ -0,0 +1,73 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/seailly/mi/token"
+)
+
+// ArrayLiteral
+type ArrayLiteral struct {
+	Token    token.Token // The [ token
+	Elements []Expression
+	RBracket token.Token // closing ]
+}
+
+func (al *ArrayLiteral) expressionNode() {}
+
+func (al *ArrayLiteral) TokenLiteral() string {
+	return al.Token.Literal
+}
+
+func (al *ArrayLiteral) Pos() token.Position {
+	return al.Token.Pos
+}
+
+func (al *ArrayLiteral) End() token.Position {
+	return al.RBracket.End
+}
+
+func (al *ArrayLiteral) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range al.Elements {
+		elements = append(elements, el.String())
+	}
+
+	out.WriteString(fmt.Sprintf("[%s]", strings.Join(elements, ", ")))
+
+	return out.String()
+}
+
+// IndexExpression
+type IndexExpression struct {
+	Token    token.Token // The [ token
+	Left     Expression
+	Index    Expression
+	RBracket token.Token // closing ]
+}
+
+func (ie *IndexExpression) expressionNode() {}
+
+func (ie *IndexExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+
+func (ie *IndexExpression) Pos() token.Position {
+	return posOf(ie.Left, ie.Token)
+}
+
+func (ie *IndexExpression) End() token.Position {
+	return ie.RBracket.End
+}
+
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(fmt.Sprintf("(%s[%s])", ie.Left.String(), ie.Index.String()))
+
+	return out.String()
+}
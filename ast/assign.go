@@ -0,0 +1,37 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/seailly/mi/token"
+)
+
+// AssignExpression Updates an existing binding, array element or hash entry
+type AssignExpression struct {
+	Token    token.Token // The = token, or a compound operator eg: +=
+	Target   Expression  // *Identifier or *IndexExpression
+	Operator string
+	Value    Expression
+}
+
+func (ae *AssignExpression) expressionNode() {}
+
+func (ae *AssignExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+
+func (ae *AssignExpression) Pos() token.Position {
+	return posOf(ae.Target, ae.Token)
+}
+
+func (ae *AssignExpression) End() token.Position {
+	return endOf(ae.Value, ae.Token)
+}
+
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(fmt.Sprintf("(%s %s %s)", ae.Target.String(), ae.Operator, ae.Value.String()))
+	return out.String()
+}
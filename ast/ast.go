@@ -1,9 +1,13 @@
 package ast
 
+import "github.com/seailly/mi/token"
+
 // Node A node in the AST
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position // position of the first character of the node
+	End() token.Position // position immediately after the node
 }
 
 // Statement Refering to code the returns a statement
@@ -17,3 +21,21 @@ type Expression interface {
 	Node
 	expressionNode()
 }
+
+// posOf Returns the position of n, falling back to tok when n failed to parse
+func posOf(n Node, tok token.Token) token.Position {
+	if n == nil {
+		return tok.Pos
+	}
+
+	return n.Pos()
+}
+
+// endOf Returns the end of n, falling back to the end of tok when n failed to parse
+func endOf(n Node, tok token.Token) token.Position {
+	if n == nil {
+		return tok.End
+	}
+
+	return n.End()
+}
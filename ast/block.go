@@ -10,6 +10,7 @@ import (
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
+	RBrace     token.Token // closing }
 }
 
 func (bs *BlockStatement) statementNode() {}
@@ -18,6 +19,14 @@ func (bs *BlockStatement) TokenLiteral() string {
 	return bs.Token.Literal
 }
 
+func (bs *BlockStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+
+func (bs *BlockStatement) End() token.Position {
+	return bs.RBrace.End
+}
+
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 
@@ -0,0 +1,25 @@
+package ast
+
+import "github.com/seailly/mi/token"
+
+// Comment A // or /* */ comment, kept when the lexer emits comments. Comments aren't part of the statements of
+// a program, tools relate them to nodes by position
+type Comment struct {
+	Token token.Token // the COMMENT token, its literal includes the delimiters
+}
+
+func (c *Comment) TokenLiteral() string {
+	return c.Token.Literal
+}
+
+func (c *Comment) Pos() token.Position {
+	return c.Token.Pos
+}
+
+func (c *Comment) End() token.Position {
+	return c.Token.End
+}
+
+func (c *Comment) String() string {
+	return c.Token.Literal
+}
@@ -17,6 +17,16 @@ func (es *ExpressionStatement) TokenLiteral() string {
 	return es.Token.Literal
 }
 
+// Pos
+func (es *ExpressionStatement) Pos() token.Position {
+	return es.Token.Pos
+}
+
+// End
+func (es *ExpressionStatement) End() token.Position {
+	return endOf(es.Expression, es.Token)
+}
+
 // String
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
@@ -11,6 +11,8 @@ type FunctionLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
 	Body       *BlockStatement
+	Slots      int   // variables each call needs, parameters included, set by the resolver
+	Captured   []int // slots of the variables nested functions refer to, set by the resolver
 }
 
 func (fl *FunctionLiteral) expressionNode() {}
@@ -19,6 +21,18 @@ func (fl *FunctionLiteral) TokenLiteral() string {
 	return fl.Token.Literal
 }
 
+func (fl *FunctionLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+
+func (fl *FunctionLiteral) End() token.Position {
+	if fl.Body == nil {
+		return fl.Token.End
+	}
+
+	return fl.Body.End()
+}
+
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
@@ -34,9 +48,10 @@ func (fl *FunctionLiteral) String() string {
 
 // CallExpression
 type CallExpression struct {
-	Token     token.Token
+	Token     token.Token // The ( token
 	Function  Expression
 	Arguments []Expression
+	RParen    token.Token // closing )
 }
 
 func (ce *CallExpression) expressionNode() {}
@@ -45,6 +60,14 @@ func (ce *CallExpression) TokenLiteral() string {
 	return ce.Token.Literal
 }
 
+func (ce *CallExpression) Pos() token.Position {
+	return posOf(ce.Function, ce.Token)
+}
+
+func (ce *CallExpression) End() token.Position {
+	return ce.RParen.End
+}
+
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 
@@ -0,0 +1,49 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/seailly/mi/token"
+)
+
+// HashPair A key: value entry of a HashLiteral
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+// HashLiteral Pairs are kept in source order
+type HashLiteral struct {
+	Token  token.Token // The { token
+	Pairs  []HashPair
+	RBrace token.Token // closing }
+}
+
+func (hl *HashLiteral) expressionNode() {}
+
+func (hl *HashLiteral) TokenLiteral() string {
+	return hl.Token.Literal
+}
+
+func (hl *HashLiteral) Pos() token.Position {
+	return hl.Token.Pos
+}
+
+func (hl *HashLiteral) End() token.Position {
+	return hl.RBrace.End
+}
+
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+
+	out.WriteString(fmt.Sprintf("{%s}", strings.Join(pairs, ", ")))
+
+	return out.String()
+}
@@ -4,10 +4,23 @@ import (
 	"github.com/seailly/mi/token"
 )
 
+// Resolution What an identifier refers to, decided by the resolver
+type Resolution int
+
+const (
+	Unresolved Resolution = iota
+	Variable              // the variable at Slot of the scope Depth levels out
+	Builtin               // a builtin function, no variable of that name is visible
+)
+
 // Identifier
 type Identifier struct {
 	Token token.Token // token.IDENT token
 	Value string
+
+	Resolution Resolution
+	Depth      int // scopes between the identifier and the declaration of its variable
+	Slot       int // index of the variable within the declaring scope
 }
 
 // expressionNode
@@ -18,6 +31,16 @@ func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+// Pos
+func (i *Identifier) Pos() token.Position {
+	return i.Token.Pos
+}
+
+// End
+func (i *Identifier) End() token.Position {
+	return i.Token.End
+}
+
 // String
 func (i *Identifier) String() string {
 	return i.Value
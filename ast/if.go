@@ -22,6 +22,22 @@ func (ie *IfExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+func (ie *IfExpression) Pos() token.Position {
+	return ie.Token.Pos
+}
+
+func (ie *IfExpression) End() token.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+
+	if ie.Consequence != nil {
+		return ie.Consequence.End()
+	}
+
+	return endOf(ie.Condition, ie.Token)
+}
+
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -22,6 +22,14 @@ func (oe *InfixExpression) TokenLiteral() string {
 	return oe.Token.Literal
 }
 
+func (oe *InfixExpression) Pos() token.Position {
+	return posOf(oe.Left, oe.Token)
+}
+
+func (oe *InfixExpression) End() token.Position {
+	return endOf(oe.Right, oe.Token)
+}
+
 func (oe *InfixExpression) String() string {
 	var out bytes.Buffer
 
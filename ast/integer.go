@@ -1,10 +1,15 @@
 package ast
 
-import "github.com/seailly/mi/token"
+import (
+	"math/big"
+
+	"github.com/seailly/mi/token"
+)
 
 type IntegerLiteral struct {
 	Token token.Token
 	Value int64
+	Big   *big.Int // set instead of Value when the literal doesn't fit in an int64
 }
 
 func (il *IntegerLiteral) expressionNode() {}
@@ -13,6 +18,14 @@ func (il *IntegerLiteral) TokenLiteral() string {
 	return il.Token.Literal
 }
 
+func (il *IntegerLiteral) Pos() token.Position {
+	return il.Token.Pos
+}
+
+func (il *IntegerLiteral) End() token.Position {
+	return il.Token.End
+}
+
 func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
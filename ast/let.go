@@ -0,0 +1,51 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/seailly/mi/token"
+)
+
+// LetStatement Binds a name that can't be reassigned, the bound value itself may still be mutated
+type LetStatement struct {
+	Token token.Token
+	Name  *Identifier
+	Value Expression
+}
+
+// statementNode
+func (ls *LetStatement) statementNode() {}
+
+// TokenLiteral Return literal from node token
+func (ls *LetStatement) TokenLiteral() string {
+	return ls.Token.Literal
+}
+
+// Pos
+func (ls *LetStatement) Pos() token.Position {
+	return ls.Token.Pos
+}
+
+// End
+func (ls *LetStatement) End() token.Position {
+	if ls.Value == nil && ls.Name != nil {
+		return ls.Name.End()
+	}
+
+	return endOf(ls.Value, ls.Token)
+}
+
+// String
+func (ls *LetStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(fmt.Sprintf("%s %s = ", ls.TokenLiteral(), ls.Name.String()))
+	if ls.Value != nil {
+		out.WriteString(ls.Value.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
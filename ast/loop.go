@@ -0,0 +1,123 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/seailly/mi/token"
+)
+
+// WhileStatement
+type WhileStatement struct {
+	Token     token.Token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode() {}
+
+func (ws *WhileStatement) TokenLiteral() string {
+	return ws.Token.Literal
+}
+
+func (ws *WhileStatement) Pos() token.Position {
+	return ws.Token.Pos
+}
+
+func (ws *WhileStatement) End() token.Position {
+	if ws.Body == nil {
+		return endOf(ws.Condition, ws.Token)
+	}
+
+	return ws.Body.End()
+}
+
+func (ws *WhileStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(fmt.Sprintf("while %s %s", ws.Condition.String(), ws.Body.String()))
+
+	return out.String()
+}
+
+// ForStatement Iterates over the items of an array, hash, string or range
+type ForStatement struct {
+	Token    token.Token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+	Slots    int   // variables each iteration needs, the loop variable included, set by the resolver
+	Captured []int // slots of the variables functions in the body refer to, set by the resolver
+}
+
+func (fs *ForStatement) statementNode() {}
+
+func (fs *ForStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+
+func (fs *ForStatement) Pos() token.Position {
+	return fs.Token.Pos
+}
+
+func (fs *ForStatement) End() token.Position {
+	if fs.Body == nil {
+		return endOf(fs.Iterable, fs.Token)
+	}
+
+	return fs.Body.End()
+}
+
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(fmt.Sprintf("for %s in %s %s", fs.Variable.String(), fs.Iterable.String(), fs.Body.String()))
+
+	return out.String()
+}
+
+// BreakStatement
+type BreakStatement struct {
+	Token token.Token
+}
+
+func (bs *BreakStatement) statementNode() {}
+
+func (bs *BreakStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+
+func (bs *BreakStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+
+func (bs *BreakStatement) End() token.Position {
+	return bs.Token.End
+}
+
+func (bs *BreakStatement) String() string {
+	return bs.Token.Literal + ";"
+}
+
+// ContinueStatement
+type ContinueStatement struct {
+	Token token.Token
+}
+
+func (cs *ContinueStatement) statementNode() {}
+
+func (cs *ContinueStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+func (cs *ContinueStatement) Pos() token.Position {
+	return cs.Token.Pos
+}
+
+func (cs *ContinueStatement) End() token.Position {
+	return cs.Token.End
+}
+
+func (cs *ContinueStatement) String() string {
+	return cs.Token.Literal + ";"
+}
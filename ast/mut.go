@@ -22,6 +22,20 @@ func (ms *MutStatement) TokenLiteral() string {
 	return ms.Token.Literal
 }
 
+// Pos
+func (ms *MutStatement) Pos() token.Position {
+	return ms.Token.Pos
+}
+
+// End
+func (ms *MutStatement) End() token.Position {
+	if ms.Value == nil && ms.Name != nil {
+		return ms.Name.End()
+	}
+
+	return endOf(ms.Value, ms.Token)
+}
+
 // String
 func (ms *MutStatement) String() string {
 	var out bytes.Buffer
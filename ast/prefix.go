@@ -19,6 +19,14 @@ func (pe *PrefixExpression) TokenLiteral() string {
 	return pe.Token.Literal
 }
 
+func (pe *PrefixExpression) Pos() token.Position {
+	return pe.Token.Pos
+}
+
+func (pe *PrefixExpression) End() token.Position {
+	return endOf(pe.Right, pe.Token)
+}
+
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -2,11 +2,15 @@ package ast
 
 import (
 	"bytes"
+
+	"github.com/seailly/mi/token"
 )
 
 // Program This is the root Node for every AST
 type Program struct {
 	Statements []Statement
+	Comments   []*Comment // in source order, only collected when the lexer emits comments
+	Resolved   bool       // every identifier has been bound by the resolver
 }
 
 // TokenLiteral
@@ -18,6 +22,24 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+// Pos
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+
+	return token.Position{}
+}
+
+// End
+func (p *Program) End() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+
+	return token.Position{}
+}
+
 // String
 func (p *Program) String() string {
 	var out bytes.Buffer
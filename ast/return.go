@@ -20,6 +20,16 @@ func (rs *ReturnStatement) TokenLiteral() string {
 	return rs.Token.Literal
 }
 
+// Pos
+func (rs *ReturnStatement) Pos() token.Position {
+	return rs.Token.Pos
+}
+
+// End
+func (rs *ReturnStatement) End() token.Position {
+	return endOf(rs.ReturnValue, rs.Token)
+}
+
 // String
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
@@ -0,0 +1,31 @@
+package ast
+
+import (
+	"strconv"
+
+	"github.com/seailly/mi/token"
+)
+
+// StringLiteral
+type StringLiteral struct {
+	Token token.Token
+	Value string // value with escape sequences decoded
+}
+
+func (sl *StringLiteral) expressionNode() {}
+
+func (sl *StringLiteral) TokenLiteral() string {
+	return sl.Token.Literal
+}
+
+func (sl *StringLiteral) Pos() token.Position {
+	return sl.Token.Pos
+}
+
+func (sl *StringLiteral) End() token.Position {
+	return sl.Token.End
+}
+
+func (sl *StringLiteral) String() string {
+	return strconv.Quote(sl.Value)
+}
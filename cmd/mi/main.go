@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/seailly/mi/repl"
+	"github.com/seailly/mi/runner"
+)
+
+const usage = `usage:
+  mi                          start the REPL
+  mi run script.mi [args...]  run a script
+  mi run --vm script.mi       run a script compiled to bytecode
+  mi script.mi [args...]      same as mi run
+`
+
+func main() {
+	args := os.Args[1:]
+
+	if len(args) > 0 && args[0] == "run" {
+		if len(args) < 2 {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		fmt.Printf("Mi v0.0.1 ")
+		repl.Start(os.Stdin, os.Stdout)
+		return
+	}
+
+	if args[0] == "-h" || args[0] == "--help" {
+		fmt.Print(usage)
+		return
+	}
+
+	engine := runner.Evaluate
+	if args[0] == "--vm" {
+		if len(args) < 2 {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+		engine = runner.Bytecode
+		args = args[1:]
+	}
+
+	os.Exit(runner.RunFile(engine, args[0], args[1:], os.Stderr))
+}
@@ -0,0 +1,238 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Instructions A sequence of encoded instructions, each an opcode followed by its operands
+type Instructions []byte
+
+// Opcode
+type Opcode byte
+
+const (
+	OpConstant Opcode = iota
+	OpNull
+	OpTrue
+	OpFalse
+	OpPop
+	OpPopN
+	OpDup2
+
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpPow
+	OpEqual
+	OpNotEqual
+	OpGreaterThan
+	OpLessThan
+	OpGreaterEqual
+	OpLessEqual
+	OpIn
+
+	OpMinus
+	OpBang
+
+	OpJump
+	OpJumpNotTruthy
+
+	OpGetVar
+	OpGetBuiltin
+	OpSetLocal
+	OpDeclare
+	OpGetAssignable
+	OpAssign
+	OpNewCell
+	OpClearLocals
+
+	OpArray
+	OpHash
+	OpCheckKey
+	OpIndex
+	OpSetIndex
+
+	OpClosure
+	OpCall
+	OpReturnValue
+
+	OpIter
+	OpIterNext
+)
+
+// Kinds of variable, the first operand of the instructions that access one
+const (
+	VarGlobal = iota // a slot of the outermost environment
+	VarLocal         // a stack slot of the frame, holding a cell instead of the value when closures capture it
+	VarFree          // a cell the closure captured, by index
+)
+
+// Definition The name of an opcode and the width in bytes of each of its operands
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}}, // constant index
+	OpNull:     {"OpNull", []int{}},
+	OpTrue:     {"OpTrue", []int{}},
+	OpFalse:    {"OpFalse", []int{}},
+	OpPop:      {"OpPop", []int{}},
+	OpPopN:     {"OpPopN", []int{2}}, // number of values
+	OpDup2:     {"OpDup2", []int{}},
+
+	OpAdd:          {"OpAdd", []int{}},
+	OpSub:          {"OpSub", []int{}},
+	OpMul:          {"OpMul", []int{}},
+	OpDiv:          {"OpDiv", []int{}},
+	OpMod:          {"OpMod", []int{}},
+	OpPow:          {"OpPow", []int{}},
+	OpEqual:        {"OpEqual", []int{}},
+	OpNotEqual:     {"OpNotEqual", []int{}},
+	OpGreaterThan:  {"OpGreaterThan", []int{}},
+	OpLessThan:     {"OpLessThan", []int{}},
+	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+	OpIn:           {"OpIn", []int{}},
+
+	OpMinus: {"OpMinus", []int{}},
+	OpBang:  {"OpBang", []int{}},
+
+	OpJump:          {"OpJump", []int{2}},          // target offset
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}}, // target offset
+
+	OpGetVar:        {"OpGetVar", []int{1, 2, 2}},        // kind, slot, name index
+	OpGetBuiltin:    {"OpGetBuiltin", []int{2}},          // name index
+	OpSetLocal:      {"OpSetLocal", []int{2}},            // slot
+	OpDeclare:       {"OpDeclare", []int{1, 2, 2, 1}},    // kind, slot, name index, 1 when mutable
+	OpGetAssignable: {"OpGetAssignable", []int{1, 2, 2}}, // kind, slot, name index
+	OpAssign:        {"OpAssign", []int{1, 2, 2}},        // kind, slot, name index
+	OpNewCell:       {"OpNewCell", []int{2}},             // slot
+	OpClearLocals:   {"OpClearLocals", []int{2, 2}},      // first slot, number of slots
+
+	OpArray:    {"OpArray", []int{2}}, // number of elements
+	OpHash:     {"OpHash", []int{2}},  // number of pairs
+	OpCheckKey: {"OpCheckKey", []int{}},
+	OpIndex:    {"OpIndex", []int{}},
+	OpSetIndex: {"OpSetIndex", []int{}},
+
+	OpClosure:     {"OpClosure", []int{2}}, // constant index of the compiled function
+	OpCall:        {"OpCall", []int{1}},    // number of arguments
+	OpReturnValue: {"OpReturnValue", []int{}},
+
+	OpIter:     {"OpIter", []int{}},
+	OpIterNext: {"OpIterNext", []int{2}}, // target offset once exhausted
+}
+
+// Lookup Find the definition of op
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+
+	return def, nil
+}
+
+// Make Encode an instruction, returning an empty slice for an unknown opcode
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	instructionLen := 1
+	for _, w := range def.OperandWidths {
+		instructionLen += w
+	}
+
+	instruction := make([]byte, instructionLen)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, o := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += width
+	}
+
+	return instruction
+}
+
+// ReadOperands Decode the operands of an instruction, returning them with the number of bytes read
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+
+		offset += width
+	}
+
+	return operands, offset
+}
+
+// ReadUint16
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+// ReadUint8
+func ReadUint8(ins Instructions) uint8 {
+	return ins[0]
+}
+
+// String Disassemble the instructions, one per line prefixed with its offset
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			i++
+			continue
+		}
+
+		operands, read := ReadOperands(def, ins[i+1:])
+
+		fmt.Fprintf(&out, "%04d %s\n", i, ins.fmtInstruction(def, operands))
+
+		i += 1 + read
+	}
+
+	return out.String()
+}
+
+// fmtInstruction
+func (ins Instructions) fmtInstruction(def *Definition, operands []int) string {
+	operandCount := len(def.OperandWidths)
+
+	if len(operands) != operandCount {
+		return fmt.Sprintf("ERROR: operand len %d does not match defined %d\n", len(operands), operandCount)
+	}
+
+	out := def.Name
+	for _, o := range operands {
+		out += fmt.Sprintf(" %d", o)
+	}
+
+	return out
+}
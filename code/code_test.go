@@ -0,0 +1,71 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		op       Opcode
+		operands []int
+		expected []byte
+	}{
+		{OpConstant, []int{65534}, []byte{byte(OpConstant), 255, 254}},
+		{OpAdd, []int{}, []byte{byte(OpAdd)}},
+		{OpCall, []int{3}, []byte{byte(OpCall), 3}},
+		{OpDeclare, []int{1, 2, 3, 1}, []byte{byte(OpDeclare), 1, 0, 2, 0, 3, 1}},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, Make(tt.op, tt.operands...))
+	}
+}
+
+func TestInstructionsString(t *testing.T) {
+	instructions := []Instructions{
+		Make(OpConstant, 1),
+		Make(OpDeclare, 0, 2, 3, 0),
+		Make(OpGetVar, 1, 2, 3),
+		Make(OpCall, 255),
+		Make(OpAdd),
+	}
+
+	expected := `0000 OpConstant 1
+0003 OpDeclare 0 2 3 0
+0010 OpGetVar 1 2 3
+0016 OpCall 255
+0018 OpAdd
+`
+
+	var concatted Instructions
+	for _, ins := range instructions {
+		concatted = append(concatted, ins...)
+	}
+
+	require.Equal(t, expected, concatted.String())
+}
+
+func TestReadOperands(t *testing.T) {
+	tests := []struct {
+		op        Opcode
+		operands  []int
+		bytesRead int
+	}{
+		{OpConstant, []int{65535}, 2},
+		{OpDeclare, []int{1, 7, 8, 1}, 6},
+		{OpGetVar, []int{255, 65535, 2}, 5},
+	}
+
+	for _, tt := range tests {
+		instruction := Make(tt.op, tt.operands...)
+
+		def, err := Lookup(byte(tt.op))
+		require.NoError(t, err)
+
+		operandsRead, n := ReadOperands(def, instruction[1:])
+		require.Equal(t, tt.bytesRead, n)
+		require.Equal(t, tt.operands, operandsRead)
+	}
+}
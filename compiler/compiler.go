@@ -0,0 +1,819 @@
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/seailly/mi/ast"
+	"github.com/seailly/mi/code"
+	"github.com/seailly/mi/diagnostic"
+	"github.com/seailly/mi/object"
+	"github.com/seailly/mi/token"
+)
+
+// ErrTooLarge Returned when a program needs more constants, names or instructions than operands can address
+var ErrTooLarge = errors.New("program too large to compile")
+
+// Bytecode The compiled main program along with the constants and names its instructions refer to
+type Bytecode struct {
+	Instructions code.Instructions
+	Spans        map[int]diagnostic.Span
+	Constants    []object.Object
+	Names        []string
+	Locals       int // stack slots for the variables of the program's loops
+}
+
+// Compiler Turns a resolved AST into bytecode. Globals live in the slots of the outermost object.Environment, as
+// they do for the evaluator, so programs run one after another share them. The variables of functions and loops
+// live in stack slots of their frame, below the values everything else pushes. Those captured by closures are kept
+// in cells instead, which the frame and its closures share
+type Compiler struct {
+	constants []object.Object
+	names     []string
+	nameIndex map[string]int
+
+	scopes     []*CompilationScope
+	scopeIndex int
+	vars       []*varScope // the scopes the resolver counts depths through, innermost last
+
+	tooLarge bool
+}
+
+// CompilationScope The instructions of the function being compiled
+type CompilationScope struct {
+	instructions code.Instructions
+	spans        map[int]diagnostic.Span
+	depth        int // values on the stack above the frame's locals, used to unwind break and continue
+	loops        []*loop
+
+	locals    int // stack slots the variables of the function and its loops need
+	next      int // first stack slot free for the variables of a nested loop
+	free      []object.Capture
+	freeIndex map[freeVar]int
+}
+
+// varScope Where the variables of a scope of the resolver are kept, in the outermost environment for the program,
+// or in the stack slots of a function from offset on
+type varScope struct {
+	global   bool
+	function *CompilationScope
+	offset   int
+}
+
+// freeVar A variable of an enclosing function that a closure captures
+type freeVar struct {
+	scope *varScope
+	slot  int
+}
+
+// loop Jumps to patch once the end of a loop is known
+type loop struct {
+	depth         int // stack depth at the start of each iteration
+	breakJumps    []int
+	continueJumps []int
+}
+
+// binaryOperators Opcodes of infix operators
+var binaryOperators = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"%":  code.OpMod,
+	"**": code.OpPow,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+	">":  code.OpGreaterThan,
+	"<":  code.OpLessThan,
+	">=": code.OpGreaterEqual,
+	"<=": code.OpLessEqual,
+	"in": code.OpIn,
+}
+
+// New
+func New() *Compiler {
+	main := newScope()
+
+	return &Compiler{
+		constants: []object.Object{},
+		names:     []string{},
+		nameIndex: make(map[string]int),
+		scopes:    []*CompilationScope{main},
+		vars:      []*varScope{{global: true, function: main}},
+	}
+}
+
+func newScope() *CompilationScope {
+	return &CompilationScope{
+		instructions: code.Instructions{},
+		spans:        make(map[int]diagnostic.Span),
+		freeIndex:    make(map[freeVar]int),
+	}
+}
+
+// Bytecode
+func (c *Compiler) Bytecode() *Bytecode {
+	return &Bytecode{
+		Instructions: c.currentScope().instructions,
+		Spans:        c.currentScope().spans,
+		Constants:    c.constants,
+		Names:        c.names,
+		Locals:       c.currentScope().locals,
+	}
+}
+
+// Compile Compile node into the main program. The program leaves the value of its last statement on the
+// stack, or nothing when that statement is a declaration
+func (c *Compiler) Compile(node ast.Node) error {
+	var err error
+
+	if program, ok := node.(*ast.Program); ok {
+		err = c.compileProgram(program)
+	} else {
+		err = c.compile(node)
+	}
+
+	if err == nil && c.tooLarge {
+		err = ErrTooLarge
+	}
+
+	return err
+}
+
+func (c *Compiler) compileProgram(program *ast.Program) error {
+	for i, s := range program.Statements {
+		if i == len(program.Statements)-1 {
+			switch s.(type) {
+			case *ast.MutStatement, *ast.LetStatement:
+				return c.compile(s)
+			default:
+				return c.compileValue(s)
+			}
+		}
+
+		if err := c.compile(s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compile Compile a statement leaving the stack as it was, or an expression pushing its value
+func (c *Compiler) compile(node ast.Node) error {
+	switch node := node.(type) {
+	// Statements
+	case *ast.ExpressionStatement:
+		if err := c.compile(node.Expression); err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+
+	case *ast.MutStatement:
+		return c.compileDeclaration(node.Name, node.Value, true)
+
+	case *ast.LetStatement:
+		return c.compileDeclaration(node.Name, node.Value, false)
+
+	case *ast.ReturnStatement:
+		if err := c.compile(node.ReturnValue); err != nil {
+			return err
+		}
+		c.emit(code.OpReturnValue)
+
+	case *ast.WhileStatement:
+		return c.compileWhile(node)
+
+	case *ast.ForStatement:
+		return c.compileFor(node)
+
+	case *ast.BreakStatement:
+		return c.compileLoopJump(node, true)
+
+	case *ast.ContinueStatement:
+		return c.compileLoopJump(node, false)
+
+	// Expressions
+	case *ast.IntegerLiteral:
+		if node.Big != nil {
+			c.emit(code.OpConstant, c.addConstant(&object.BigInt{Value: node.Big}))
+		} else {
+			c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: node.Value}))
+		}
+
+	case *ast.FloatLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Float{Value: node.Value}))
+
+	case *ast.StringLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: node.Value}))
+
+	case *ast.Boolean:
+		if node.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+
+	case *ast.PrefixExpression:
+		if err := c.compile(node.Right); err != nil {
+			return err
+		}
+
+		switch node.Operator {
+		case "-":
+			c.emitAt(node.Pos(), node.End(), code.OpMinus)
+		case "!":
+			c.emitAt(node.Pos(), node.End(), code.OpBang)
+		default:
+			return fmt.Errorf("unknown operator %s", node.Operator)
+		}
+
+	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return c.compileLogical(node)
+		}
+
+		if err := c.compile(node.Left); err != nil {
+			return err
+		}
+
+		if err := c.compile(node.Right); err != nil {
+			return err
+		}
+
+		return c.compileOperator(node.Operator, node.Token)
+
+	case *ast.IfExpression:
+		return c.compileIf(node)
+
+	case *ast.Identifier:
+		switch node.Resolution {
+		case ast.Variable:
+			kind, slot := c.variable(node)
+			c.emitAt(node.Pos(), node.End(), code.OpGetVar, kind, slot, c.addName(node.Value))
+		case ast.Builtin:
+			c.emitAt(node.Pos(), node.End(), code.OpGetBuiltin, c.addName(node.Value))
+		default:
+			return unresolved(node)
+		}
+
+	case *ast.FunctionLiteral:
+		return c.compileFunction(node)
+
+	case *ast.CallExpression:
+		if err := c.compile(node.Function); err != nil {
+			return err
+		}
+
+		for _, arg := range node.Arguments {
+			if err := c.compile(arg); err != nil {
+				return err
+			}
+		}
+
+		c.emitAt(node.Pos(), node.End(), code.OpCall, len(node.Arguments))
+
+	case *ast.ArrayLiteral:
+		for _, el := range node.Elements {
+			if err := c.compile(el); err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpArray, len(node.Elements))
+
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			if err := c.compile(pair.Key); err != nil {
+				return err
+			}
+			c.emitAt(pair.Key.Pos(), pair.Key.End(), code.OpCheckKey)
+
+			if err := c.compile(pair.Value); err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpHash, len(node.Pairs))
+
+	case *ast.IndexExpression:
+		if err := c.compile(node.Left); err != nil {
+			return err
+		}
+
+		if err := c.compile(node.Index); err != nil {
+			return err
+		}
+
+		c.emitAt(node.Token.Pos, node.End(), code.OpIndex)
+
+	case *ast.AssignExpression:
+		return c.compileAssign(node)
+
+	default:
+		return fmt.Errorf("cannot compile %T", node)
+	}
+
+	return nil
+}
+
+// compileValue Compile a statement so it pushes its value, as the last statement of a block does
+func (c *Compiler) compileValue(s ast.Statement) error {
+	if es, ok := s.(*ast.ExpressionStatement); ok {
+		return c.compile(es.Expression)
+	}
+
+	if err := c.compile(s); err != nil {
+		return err
+	}
+
+	// Loops and declarations have no value, and nothing runs after return, break or continue
+	c.emit(code.OpNull)
+
+	return nil
+}
+
+// compileBlock Compile a block so it pushes the value of its last statement, or null when empty
+func (c *Compiler) compileBlock(block *ast.BlockStatement) error {
+	if len(block.Statements) == 0 {
+		c.emit(code.OpNull)
+		return nil
+	}
+
+	last := len(block.Statements) - 1
+	for _, s := range block.Statements[:last] {
+		if err := c.compile(s); err != nil {
+			return err
+		}
+	}
+
+	return c.compileValue(block.Statements[last])
+}
+
+// compileOperator Emit the opcode of a binary operator, errors are reported at the operator token
+func (c *Compiler) compileOperator(operator string, tok token.Token) error {
+	op, ok := binaryOperators[operator]
+	if !ok {
+		return fmt.Errorf("unknown operator %s", operator)
+	}
+
+	c.emitAt(tok.Pos, tok.End, op)
+
+	return nil
+}
+
+func (c *Compiler) compileDeclaration(name *ast.Identifier, value ast.Expression, mutable bool) error {
+	if err := c.compile(value); err != nil {
+		return err
+	}
+
+	flag := 0
+	if mutable {
+		flag = 1
+	}
+
+	kind, slot := c.variable(name)
+	c.emitAt(name.Pos(), name.End(), code.OpDeclare, kind, slot, c.addName(name.Value), flag)
+
+	return nil
+}
+
+func (c *Compiler) compileIf(node *ast.IfExpression) error {
+	if err := c.compile(node.Condition); err != nil {
+		return err
+	}
+
+	jumpNotTruthy := c.emit(code.OpJumpNotTruthy, 0)
+	depth := c.currentScope().depth
+
+	if err := c.compileBlock(node.Consequence); err != nil {
+		return err
+	}
+
+	jump := c.emit(code.OpJump, 0)
+	c.patchJump(jumpNotTruthy)
+
+	// Only one branch runs, each pushes a single value
+	c.currentScope().depth = depth
+
+	if node.Alternative == nil {
+		c.emit(code.OpNull)
+	} else if err := c.compileBlock(node.Alternative); err != nil {
+		return err
+	}
+
+	c.patchJump(jump)
+
+	return nil
+}
+
+// compileLogical Jump over the right operand when the left decides the result, pushing true or false as
+// evaluator.shortCircuit does
+func (c *Compiler) compileLogical(node *ast.InfixExpression) error {
+	if err := c.compile(node.Left); err != nil {
+		return err
+	}
+
+	var skipRight, ends []int
+	if node.Operator == "||" {
+		// The left operand is true
+		right := c.emit(code.OpJumpNotTruthy, 0)
+		c.emit(code.OpTrue)
+		ends = append(ends, c.emit(code.OpJump, 0))
+		c.patchJump(right)
+		c.currentScope().depth--
+	} else {
+		skipRight = append(skipRight, c.emit(code.OpJumpNotTruthy, 0))
+	}
+
+	if err := c.compile(node.Right); err != nil {
+		return err
+	}
+
+	falsy := c.emit(code.OpJumpNotTruthy, 0)
+	c.emit(code.OpTrue)
+	ends = append(ends, c.emit(code.OpJump, 0))
+
+	// Only one of the results is pushed
+	c.currentScope().depth--
+	c.patchJumps(append(skipRight, falsy), len(c.currentInstructions()))
+	c.emit(code.OpFalse)
+	c.patchJumps(ends, len(c.currentInstructions()))
+
+	return nil
+}
+
+// compileFunction The parameters are the first locals of a call, the vm moves the arguments there. Captured
+// variables get their cells before the body runs, as closures may refer to variables declared after them
+func (c *Compiler) compileFunction(node *ast.FunctionLiteral) error {
+	c.enterScope()
+
+	scope := c.currentScope()
+	scope.locals = node.Slots
+	scope.next = node.Slots
+	c.vars = append(c.vars, &varScope{function: scope})
+
+	for _, slot := range node.Captured {
+		c.emit(code.OpNewCell, slot)
+	}
+
+	if err := c.compileBlock(node.Body); err != nil {
+		return err
+	}
+	c.emit(code.OpReturnValue)
+
+	c.vars = c.vars[:len(c.vars)-1]
+	c.leaveScope()
+
+	fn := &object.CompiledFunction{
+		Instructions: scope.instructions,
+		Spans:        scope.spans,
+		Parameters:   node.Parameters,
+		Locals:       scope.locals,
+		Free:         scope.free,
+		Body:         node.Body,
+	}
+
+	c.emit(code.OpClosure, c.addConstant(fn))
+
+	return nil
+}
+
+func (c *Compiler) compileAssign(node *ast.AssignExpression) error {
+	compound := node.Operator != "="
+	operator := node.Operator[:len(node.Operator)-1]
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		if target.Resolution != ast.Variable {
+			return unresolved(target)
+		}
+
+		name := c.addName(target.Value)
+		kind, slot := c.variable(target)
+
+		// Checks the variable is bound before the value runs, the current value is only needed by compound operators
+		c.emitAt(target.Pos(), target.End(), code.OpGetAssignable, kind, slot, name)
+		if !compound {
+			c.emit(code.OpPop)
+		}
+
+		if err := c.compile(node.Value); err != nil {
+			return err
+		}
+
+		if compound {
+			if err := c.compileOperator(operator, node.Token); err != nil {
+				return err
+			}
+		}
+
+		c.emitAt(target.Pos(), target.End(), code.OpAssign, kind, slot, name)
+
+	case *ast.IndexExpression:
+		if err := c.compile(target.Left); err != nil {
+			return err
+		}
+
+		if err := c.compile(target.Index); err != nil {
+			return err
+		}
+
+		if compound {
+			c.emit(code.OpDup2)
+			c.emitAt(target.Token.Pos, target.End(), code.OpIndex)
+		}
+
+		if err := c.compile(node.Value); err != nil {
+			return err
+		}
+
+		if compound {
+			if err := c.compileOperator(operator, node.Token); err != nil {
+				return err
+			}
+		}
+
+		c.emitAt(target.Token.Pos, target.End(), code.OpSetIndex)
+
+	default:
+		return fmt.Errorf("cannot assign to %s", node.Target.String())
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileWhile(node *ast.WhileStatement) error {
+	top := len(c.currentInstructions())
+
+	if err := c.compile(node.Condition); err != nil {
+		return err
+	}
+
+	exit := c.emit(code.OpJumpNotTruthy, 0)
+
+	l := c.enterLoop()
+	if err := c.compileLoopBody(node.Body); err != nil {
+		return err
+	}
+	c.leaveLoop()
+
+	c.patchJumps(l.continueJumps, top)
+	c.emit(code.OpJump, top)
+
+	c.patchJump(exit)
+	c.patchJumps(l.breakJumps, len(c.currentInstructions()))
+
+	return nil
+}
+
+// compileFor The iterator stays on the stack for the whole loop. The variables of the body take the locals after
+// those of the enclosing scopes, and start each iteration unbound with new cells, so closures created in the body
+// see their own item
+func (c *Compiler) compileFor(node *ast.ForStatement) error {
+	if err := c.compile(node.Iterable); err != nil {
+		return err
+	}
+
+	c.emitAt(node.Iterable.Pos(), node.Iterable.End(), code.OpIter)
+
+	scope := c.currentScope()
+	vars := &varScope{function: scope, offset: scope.next}
+	c.vars = append(c.vars, vars)
+	scope.next += node.Slots
+	if scope.next > scope.locals {
+		scope.locals = scope.next
+	}
+
+	top := len(c.currentInstructions())
+	l := c.enterLoop()
+
+	exit := c.emit(code.OpIterNext, 0)
+	if node.Slots > 1 || len(node.Captured) > 0 {
+		c.emit(code.OpClearLocals, vars.offset, node.Slots)
+	}
+	for _, slot := range node.Captured {
+		c.emit(code.OpNewCell, vars.offset+slot)
+	}
+	c.emit(code.OpSetLocal, vars.offset+node.Variable.Slot)
+
+	if err := c.compileLoopBody(node.Body); err != nil {
+		return err
+	}
+	c.leaveLoop()
+
+	c.patchJumps(l.continueJumps, top)
+	c.emit(code.OpJump, top)
+
+	c.patchJump(exit)
+	c.patchJumps(l.breakJumps, len(c.currentInstructions()))
+
+	c.vars = c.vars[:len(c.vars)-1]
+	scope.next -= node.Slots
+
+	// Breaking out and running out of items both arrive here with only the iterator left
+	c.currentScope().depth = l.depth
+	c.emit(code.OpPop)
+
+	return nil
+}
+
+func (c *Compiler) compileLoopBody(body *ast.BlockStatement) error {
+	for _, s := range body.Statements {
+		if err := c.compile(s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compileLoopJump Drop the values pushed since the loop started, as break and continue can sit inside an
+// expression, then jump to the end or the next iteration of the innermost loop
+func (c *Compiler) compileLoopJump(node ast.Statement, isBreak bool) error {
+	scope := c.currentScope()
+	if len(scope.loops) == 0 {
+		return fmt.Errorf("%s outside of a loop", node.TokenLiteral())
+	}
+
+	l := scope.loops[len(scope.loops)-1]
+	depth := scope.depth
+
+	if extra := depth - l.depth; extra > 0 {
+		c.emit(code.OpPopN, extra)
+	}
+
+	if isBreak {
+		l.breakJumps = append(l.breakJumps, c.emit(code.OpJump, 0))
+	} else {
+		l.continueJumps = append(l.continueJumps, c.emit(code.OpJump, 0))
+	}
+
+	// Code after the jump never runs, it is compiled as if the values were still there
+	scope.depth = depth
+
+	return nil
+}
+
+func (c *Compiler) enterLoop() *loop {
+	scope := c.currentScope()
+	l := &loop{depth: scope.depth}
+	scope.loops = append(scope.loops, l)
+
+	return l
+}
+
+func (c *Compiler) leaveLoop() {
+	scope := c.currentScope()
+	scope.loops = scope.loops[:len(scope.loops)-1]
+}
+
+// variable The kind and slot of the variable ident is bound to, see code.VarGlobal
+func (c *Compiler) variable(ident *ast.Identifier) (int, int) {
+	vars := c.vars[len(c.vars)-1-ident.Depth]
+
+	switch {
+	case vars.global:
+		return code.VarGlobal, ident.Slot
+	case vars.function == c.currentScope():
+		return code.VarLocal, vars.offset + ident.Slot
+	default:
+		return code.VarFree, c.free(c.scopeIndex, freeVar{scope: vars, slot: ident.Slot})
+	}
+}
+
+// free The index of v among the free variables of the function at scopeIndex, capturing it through every function
+// in between
+func (c *Compiler) free(scopeIndex int, v freeVar) int {
+	scope := c.scopes[scopeIndex]
+	if i, ok := scope.freeIndex[v]; ok {
+		return i
+	}
+
+	capture := object.Capture{Local: true, Index: v.scope.offset + v.slot}
+	if v.scope.function != c.scopes[scopeIndex-1] {
+		capture = object.Capture{Index: c.free(scopeIndex-1, v)}
+	}
+
+	scope.free = append(scope.free, capture)
+	scope.freeIndex[v] = len(scope.free) - 1
+
+	return len(scope.free) - 1
+}
+
+// addConstant
+func (c *Compiler) addConstant(obj object.Object) int {
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1
+}
+
+// addName Index of name in the name table, adding it if needed
+func (c *Compiler) addName(name string) int {
+	if i, ok := c.nameIndex[name]; ok {
+		return i
+	}
+
+	c.names = append(c.names, name)
+	c.nameIndex[name] = len(c.names) - 1
+
+	return len(c.names) - 1
+}
+
+// emit Append an instruction, returning its offset
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	def, _ := code.Lookup(byte(op))
+	for i, operand := range operands {
+		if operand > 1<<(8*def.OperandWidths[i])-1 {
+			c.tooLarge = true
+		}
+	}
+
+	scope := c.currentScope()
+	pos := len(scope.instructions)
+	scope.instructions = append(scope.instructions, code.Make(op, operands...)...)
+	scope.depth += stackEffect(op, operands)
+
+	return pos
+}
+
+// emitAt Append an instruction that can raise an error, recording the source it reports
+func (c *Compiler) emitAt(pos token.Position, end token.Position, op code.Opcode, operands ...int) int {
+	offset := c.emit(op, operands...)
+	c.currentScope().spans[offset] = diagnostic.Span{Start: pos, End: end}
+
+	return offset
+}
+
+// patchJump Point the jump at offset to the end of the current instructions
+func (c *Compiler) patchJump(offset int) {
+	c.changeOperand(offset, len(c.currentInstructions()))
+}
+
+func (c *Compiler) patchJumps(offsets []int, target int) {
+	for _, offset := range offsets {
+		c.changeOperand(offset, target)
+	}
+}
+
+func (c *Compiler) changeOperand(offset int, operand int) {
+	if operand > math.MaxUint16 {
+		c.tooLarge = true
+	}
+
+	ins := c.currentInstructions()
+	op := code.Opcode(ins[offset])
+	copy(ins[offset:], code.Make(op, operand))
+}
+
+func (c *Compiler) currentScope() *CompilationScope {
+	return c.scopes[c.scopeIndex]
+}
+
+func (c *Compiler) currentInstructions() code.Instructions {
+	return c.currentScope().instructions
+}
+
+func (c *Compiler) enterScope() {
+	c.scopes = append(c.scopes, newScope())
+	c.scopeIndex++
+}
+
+func (c *Compiler) leaveScope() *CompilationScope {
+	scope := c.currentScope()
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.scopeIndex--
+
+	return scope
+}
+
+// unresolved Programs must be resolved before they are compiled
+func unresolved(ident *ast.Identifier) error {
+	return fmt.Errorf("unresolved identifier %s", ident.Value)
+}
+
+// stackEffect How many values op leaves on the stack, less the number it takes
+func stackEffect(op code.Opcode, operands []int) int {
+	switch op {
+	case code.OpConstant, code.OpNull, code.OpTrue, code.OpFalse, code.OpGetVar, code.OpGetBuiltin,
+		code.OpGetAssignable, code.OpClosure, code.OpIterNext:
+		return 1
+	case code.OpDup2:
+		return 2
+	case code.OpPop, code.OpJumpNotTruthy, code.OpSetLocal, code.OpDeclare, code.OpReturnValue, code.OpIndex,
+		code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMod, code.OpPow,
+		code.OpEqual, code.OpNotEqual, code.OpGreaterThan, code.OpLessThan, code.OpGreaterEqual, code.OpLessEqual,
+		code.OpIn:
+		return -1
+	case code.OpSetIndex:
+		return -2
+	case code.OpPopN, code.OpCall:
+		return -operands[0]
+	case code.OpArray:
+		return 1 - operands[0]
+	case code.OpHash:
+		return 1 - 2*operands[0]
+	default:
+		return 0
+	}
+}
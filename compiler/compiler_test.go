@@ -0,0 +1,212 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/seailly/mi/code"
+	"github.com/seailly/mi/evaluator"
+	"github.com/seailly/mi/lexer"
+	"github.com/seailly/mi/object"
+	"github.com/seailly/mi/parser"
+	"github.com/seailly/mi/resolver"
+	"github.com/stretchr/testify/require"
+)
+
+func compile(t *testing.T, input string) *Bytecode {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors(), input)
+	require.Empty(t, resolver.Resolve(program, object.NewEnvironment(), evaluator.IsBuiltin), input)
+
+	c := New()
+	require.NoError(t, c.Compile(program), input)
+
+	return c.Bytecode()
+}
+
+func concat(instructions ...[]byte) code.Instructions {
+	var out code.Instructions
+	for _, ins := range instructions {
+		out = append(out, ins...)
+	}
+
+	return out
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected code.Instructions
+	}{
+		{"1 + 2", concat(
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpConstant, 1),
+			code.Make(code.OpAdd),
+		)},
+		{"1; 2", concat(
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpPop),
+			code.Make(code.OpConstant, 1),
+		)},
+		{"mut a = 1; let b = a", concat(
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpDeclare, code.VarGlobal, 0, 0, 1),
+			code.Make(code.OpGetVar, code.VarGlobal, 0, 0),
+			code.Make(code.OpDeclare, code.VarGlobal, 1, 1, 0),
+		)},
+		{"if (true) { 10 }", concat(
+			code.Make(code.OpTrue),
+			code.Make(code.OpJumpNotTruthy, 10),
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpJump, 11),
+			code.Make(code.OpNull),
+		)},
+		{"1 <= 2; 1 >= 2", concat(
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpConstant, 1),
+			code.Make(code.OpLessEqual),
+			code.Make(code.OpPop),
+			code.Make(code.OpConstant, 2),
+			code.Make(code.OpConstant, 3),
+			code.Make(code.OpGreaterEqual),
+		)},
+		{"true && false", concat(
+			code.Make(code.OpTrue),
+			code.Make(code.OpJumpNotTruthy, 12),
+			code.Make(code.OpFalse),
+			code.Make(code.OpJumpNotTruthy, 12),
+			code.Make(code.OpTrue),
+			code.Make(code.OpJump, 13),
+			code.Make(code.OpFalse),
+		)},
+		{"true || false", concat(
+			code.Make(code.OpTrue),
+			code.Make(code.OpJumpNotTruthy, 8),
+			code.Make(code.OpTrue),
+			code.Make(code.OpJump, 17),
+			code.Make(code.OpFalse),
+			code.Make(code.OpJumpNotTruthy, 16),
+			code.Make(code.OpTrue),
+			code.Make(code.OpJump, 17),
+			code.Make(code.OpFalse),
+		)},
+		{"mut a = 1; a += 2", concat(
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpDeclare, code.VarGlobal, 0, 0, 1),
+			code.Make(code.OpGetAssignable, code.VarGlobal, 0, 0),
+			code.Make(code.OpConstant, 1),
+			code.Make(code.OpAdd),
+			code.Make(code.OpAssign, code.VarGlobal, 0, 0),
+		)},
+		{"while (true) { break }", concat(
+			code.Make(code.OpTrue),
+			code.Make(code.OpJumpNotTruthy, 10),
+			code.Make(code.OpJump, 10),
+			code.Make(code.OpJump, 0),
+			code.Make(code.OpNull),
+		)},
+		{"for (x in [1]) { x; }", concat(
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpArray, 1),
+			code.Make(code.OpIter),
+			code.Make(code.OpIterNext, 23),
+			code.Make(code.OpSetLocal, 0),
+			code.Make(code.OpGetVar, code.VarLocal, 0, 0),
+			code.Make(code.OpPop),
+			code.Make(code.OpJump, 7),
+			code.Make(code.OpPop),
+			code.Make(code.OpNull),
+		)},
+	}
+
+	for _, tt := range tests {
+		bytecode := compile(t, tt.input)
+		require.Equal(t, tt.expected.String(), bytecode.Instructions.String(), tt.input)
+	}
+}
+
+func TestCompileBreakUnwindsStack(t *testing.T) {
+	bytecode := compile(t, "for (x in [1]) { [1, if (true) { break }] }")
+
+	require.Equal(t, `0000 OpConstant 0
+0003 OpArray 1
+0006 OpIter
+0007 OpIterNext 38
+0010 OpSetLocal 0
+0013 OpConstant 1
+0016 OpTrue
+0017 OpJumpNotTruthy 30
+0020 OpPopN 1
+0023 OpJump 38
+0026 OpNull
+0027 OpJump 31
+0030 OpNull
+0031 OpArray 2
+0034 OpPop
+0035 OpJump 7
+0038 OpPop
+0039 OpNull
+`, bytecode.Instructions.String())
+}
+
+func TestCompileClosures(t *testing.T) {
+	bytecode := compile(t, "mut f = fn(a) { mut b = 1; fn() { fn() { a + b } } }")
+
+	outer := bytecode.Constants[3].(*object.CompiledFunction)
+	require.Equal(t, 2, outer.Locals)
+	require.Empty(t, outer.Free)
+	require.Equal(t, concat(
+		code.Make(code.OpNewCell, 0),
+		code.Make(code.OpNewCell, 1),
+		code.Make(code.OpConstant, 0),
+		code.Make(code.OpDeclare, code.VarLocal, 1, 0, 1),
+		code.Make(code.OpClosure, 2),
+		code.Make(code.OpReturnValue),
+	).String(), outer.Instructions.String())
+
+	// Captured from the function creating the closure, or passed on from its own free variables
+	middle := bytecode.Constants[2].(*object.CompiledFunction)
+	require.Equal(t, []object.Capture{{Local: true, Index: 0}, {Local: true, Index: 1}}, middle.Free)
+
+	inner := bytecode.Constants[1].(*object.CompiledFunction)
+	require.Equal(t, []object.Capture{{Index: 0}, {Index: 1}}, inner.Free)
+	require.Equal(t, concat(
+		code.Make(code.OpGetVar, code.VarFree, 0, 1),
+		code.Make(code.OpGetVar, code.VarFree, 1, 0),
+		code.Make(code.OpAdd),
+		code.Make(code.OpReturnValue),
+	).String(), inner.Instructions.String())
+}
+
+func TestCompileLoopLocals(t *testing.T) {
+	bytecode := compile(t, "mut f = fn() { for (x in [1]) { mut y = x; fn() { x } } }")
+
+	fn := bytecode.Constants[2].(*object.CompiledFunction)
+	require.Equal(t, 2, fn.Locals)
+	require.Equal(t, concat(
+		code.Make(code.OpConstant, 0),
+		code.Make(code.OpArray, 1),
+		code.Make(code.OpIter),
+		code.Make(code.OpIterNext, 41),
+		code.Make(code.OpClearLocals, 0, 2),
+		code.Make(code.OpNewCell, 0),
+		code.Make(code.OpSetLocal, 0),
+		code.Make(code.OpGetVar, code.VarLocal, 0, 0),
+		code.Make(code.OpDeclare, code.VarLocal, 1, 1, 1),
+		code.Make(code.OpClosure, 1),
+		code.Make(code.OpPop),
+		code.Make(code.OpJump, 7),
+		code.Make(code.OpPop),
+		code.Make(code.OpNull),
+		code.Make(code.OpReturnValue),
+	).String(), fn.Instructions.String())
+}
+
+func TestCompileSpans(t *testing.T) {
+	bytecode := compile(t, "len + 1")
+
+	require.Equal(t, "1:1", bytecode.Spans[0].Start.String())
+	require.Equal(t, "1:5", bytecode.Spans[6].Start.String())
+}
@@ -0,0 +1,470 @@
+package mi
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/seailly/mi/evaluator"
+	"github.com/seailly/mi/object"
+)
+
+var (
+	objectType = reflect.TypeOf((*object.Object)(nil)).Elem()
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+	bigIntType = reflect.TypeOf((*big.Int)(nil))
+)
+
+// conversionError A value that can't be converted to the Go type a parameter needs. Path locates the value within
+// the argument, as in [1]["name"]
+type conversionError struct {
+	path string
+	msg  string
+}
+
+func (e *conversionError) Error() string {
+	return e.msg
+}
+
+// within Prefix the path of err with the element it was found in
+func within(err error, element string) error {
+	if convErr, ok := err.(*conversionError); ok {
+		return &conversionError{path: element + convErr.path, msg: convErr.msg}
+	}
+
+	return err
+}
+
+// wrapFunction Turn fn into a builtin, checking its parameter and result types up front so a call can only fail on
+// the values it is given
+func wrapFunction(name string, fn interface{}) (*object.Builtin, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("mi: cannot register %s: %T is not a function", name, fn)
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumIn(); i++ {
+		in := t.In(i)
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			in = in.Elem()
+		}
+
+		if !convertible(in) {
+			return nil, fmt.Errorf("mi: cannot register %s: unsupported parameter type %s", name, in)
+		}
+	}
+
+	returnsError := t.NumOut() > 0 && t.Out(t.NumOut()-1) == errorType
+	results := t.NumOut()
+	if returnsError {
+		results--
+	}
+
+	if results > 1 {
+		return nil, fmt.Errorf("mi: cannot register %s: functions return at most one value and an error", name)
+	}
+
+	if results == 1 && !convertible(t.Out(0)) {
+		return nil, fmt.Errorf("mi: cannot register %s: unsupported result type %s", name, t.Out(0))
+	}
+
+	call := func(_ *object.Environment, args ...object.Object) object.Object {
+		in, errObj := arguments(name, t, args)
+		if errObj != nil {
+			return errObj
+		}
+
+		out := v.Call(in)
+
+		if returnsError {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				return &object.Error{Message: fmt.Sprintf("%s: %s", name, err), Cause: err}
+			}
+		}
+
+		if results == 0 {
+			return evaluator.NULL
+		}
+
+		result, err := toObject(out[0])
+		if err != nil {
+			return &object.Error{Message: fmt.Sprintf("result of %s: %s", name, err)}
+		}
+
+		return result
+	}
+
+	return &object.Builtin{Name: name, Fn: call}, nil
+}
+
+// arguments Convert the arguments of a call to the parameter types of t
+func arguments(name string, t reflect.Type, args []object.Object) ([]reflect.Value, *object.Error) {
+	params := t.NumIn()
+	if t.IsVariadic() {
+		params--
+		if len(args) < params {
+			return nil, &object.Error{Message: fmt.Sprintf(
+				"wrong number of arguments to %s: got %d, want at least %d", name, len(args), params)}
+		}
+	} else if len(args) != params {
+		return nil, &object.Error{Message: fmt.Sprintf(
+			"wrong number of arguments to %s: got %d, want %d", name, len(args), params)}
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var param reflect.Type
+		if i < params {
+			param = t.In(i)
+		} else {
+			param = t.In(params).Elem()
+		}
+
+		v, err := fromObject(arg, param)
+		if err != nil {
+			msg := fmt.Sprintf("argument %d to %s", i+1, name)
+			if convErr, ok := err.(*conversionError); ok && convErr.path != "" {
+				msg += " at " + convErr.path
+			}
+
+			return nil, &object.Error{Message: msg + ": " + err.Error()}
+		}
+
+		in[i] = v
+	}
+
+	return in, nil
+}
+
+// convertible Whether values of t can be passed to and from scripts
+func convertible(t reflect.Type) bool {
+	if t == objectType || t == bigIntType {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Interface:
+		return t.NumMethod() == 0
+	case reflect.Slice, reflect.Array:
+		return convertible(t.Elem())
+	case reflect.Map:
+		return convertible(t.Key()) && convertible(t.Elem())
+	default:
+		return false
+	}
+}
+
+// fromObject Convert obj to a Go value of type t. Integers widen to floats, null becomes the zero value of slices,
+// maps and interfaces, and interface{} receives int64, float64, *big.Int, bool, string, nil, []interface{} or
+// map[interface{}]interface{}
+func fromObject(obj object.Object, t reflect.Type) (reflect.Value, error) {
+	mismatch := func() (reflect.Value, error) {
+		return reflect.Value{}, &conversionError{msg: fmt.Sprintf("cannot use %s as %s", obj.Type(), t)}
+	}
+
+	if t == objectType {
+		return reflect.ValueOf(&obj).Elem(), nil
+	}
+
+	if _, ok := obj.(*object.Null); ok {
+		switch t.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Interface, reflect.Ptr:
+			return reflect.Zero(t), nil
+		}
+
+		return mismatch()
+	}
+
+	if t == bigIntType {
+		switch obj := obj.(type) {
+		case *object.BigInt:
+			return reflect.ValueOf(new(big.Int).Set(obj.Value)), nil
+		case *object.Integer:
+			return reflect.ValueOf(big.NewInt(obj.Value)), nil
+		}
+
+		return mismatch()
+	}
+
+	v := reflect.New(t).Elem()
+
+	switch t.Kind() {
+	case reflect.Bool:
+		b, ok := obj.(*object.Boolean)
+		if !ok {
+			return mismatch()
+		}
+		v.SetBool(b.Value)
+
+	case reflect.String:
+		s, ok := obj.(*object.String)
+		if !ok {
+			return mismatch()
+		}
+		v.SetString(s.Value)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch i := obj.(type) {
+		case *object.Integer:
+			if v.OverflowInt(i.Value) {
+				return reflect.Value{}, &conversionError{msg: fmt.Sprintf("%s overflows %s", obj.Inspect(), t)}
+			}
+			v.SetInt(i.Value)
+		case *object.BigInt:
+			return reflect.Value{}, &conversionError{msg: fmt.Sprintf("%s overflows %s", obj.Inspect(), t)}
+		default:
+			return mismatch()
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n *big.Int
+		switch obj := obj.(type) {
+		case *object.Integer:
+			n = big.NewInt(obj.Value)
+		case *object.BigInt:
+			n = obj.Value
+		default:
+			return mismatch()
+		}
+		if n.Sign() < 0 || !n.IsUint64() || v.OverflowUint(n.Uint64()) {
+			return reflect.Value{}, &conversionError{msg: fmt.Sprintf("%s overflows %s", obj.Inspect(), t)}
+		}
+		v.SetUint(n.Uint64())
+
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch obj := obj.(type) {
+		case *object.Float:
+			f = obj.Value
+		case *object.Integer:
+			f = float64(obj.Value)
+		default:
+			return mismatch()
+		}
+
+		if v.OverflowFloat(f) {
+			return reflect.Value{}, &conversionError{msg: fmt.Sprintf("%s overflows %s", obj.Inspect(), t)}
+		}
+		v.SetFloat(f)
+
+	case reflect.Slice, reflect.Array:
+		arr, ok := obj.(*object.Array)
+		if !ok {
+			return mismatch()
+		}
+
+		if t.Kind() == reflect.Slice {
+			v = reflect.MakeSlice(t, len(arr.Elements), len(arr.Elements))
+		} else if len(arr.Elements) != t.Len() {
+			return reflect.Value{}, &conversionError{
+				msg: fmt.Sprintf("cannot use array of %d elements as %s", len(arr.Elements), t)}
+		}
+
+		for i, el := range arr.Elements {
+			elem, err := fromObject(el, t.Elem())
+			if err != nil {
+				return reflect.Value{}, within(err, fmt.Sprintf("[%d]", i))
+			}
+			v.Index(i).Set(elem)
+		}
+
+	case reflect.Map:
+		hash, ok := obj.(*object.Hash)
+		if !ok {
+			return mismatch()
+		}
+
+		v = reflect.MakeMapWithSize(t, hash.Len())
+		for _, pair := range hash.Pairs() {
+			key, err := fromObject(pair.Key, t.Key())
+			if err != nil {
+				return reflect.Value{}, within(err, "key "+pair.Key.Inspect())
+			}
+
+			val, err := fromObject(pair.Value, t.Elem())
+			if err != nil {
+				return reflect.Value{}, within(err, "["+pair.Key.Inspect()+"]")
+			}
+			v.SetMapIndex(key, val)
+		}
+
+	case reflect.Interface:
+		native, err := toNative(obj)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if native != nil {
+			v.Set(reflect.ValueOf(native))
+		}
+
+	default:
+		return mismatch()
+	}
+
+	return v, nil
+}
+
+// toNative The natural Go value of obj, for parameters of type interface{}
+func toNative(obj object.Object) (interface{}, error) {
+	switch obj := obj.(type) {
+	case *object.Null:
+		return nil, nil
+	case *object.Boolean:
+		return obj.Value, nil
+	case *object.Integer:
+		return obj.Value, nil
+	case *object.BigInt:
+		return new(big.Int).Set(obj.Value), nil
+	case *object.Float:
+		return obj.Value, nil
+	case *object.String:
+		return obj.Value, nil
+	case *object.Array:
+		elements := make([]interface{}, len(obj.Elements))
+		for i, el := range obj.Elements {
+			native, err := toNative(el)
+			if err != nil {
+				return nil, within(err, fmt.Sprintf("[%d]", i))
+			}
+			elements[i] = native
+		}
+
+		return elements, nil
+	case *object.Hash:
+		m := make(map[interface{}]interface{}, obj.Len())
+		for _, pair := range obj.Pairs() {
+			key, _ := toNative(pair.Key)
+			val, err := toNative(pair.Value)
+			if err != nil {
+				return nil, within(err, "["+pair.Key.Inspect()+"]")
+			}
+			m[key] = val
+		}
+
+		return m, nil
+	default:
+		return nil, &conversionError{msg: fmt.Sprintf("cannot use %s as interface {}", obj.Type())}
+	}
+}
+
+// toObject Convert a Go value to the object scripts see. Unsigned integers beyond int64 become big integers, nil
+// becomes null, and the keys of maps are sorted so hashes have a stable order
+func toObject(v reflect.Value) (object.Object, error) {
+	if !v.IsValid() {
+		return evaluator.NULL, nil
+	}
+
+	if v.Type() == objectType {
+		if v.IsNil() {
+			return evaluator.NULL, nil
+		}
+
+		return v.Interface().(object.Object), nil
+	}
+
+	if v.Type() == bigIntType {
+		if v.IsNil() {
+			return evaluator.NULL, nil
+		}
+
+		return object.NewBigInt(new(big.Int).Set(v.Interface().(*big.Int))), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return evaluator.TRUE, nil
+		}
+
+		return evaluator.FALSE, nil
+
+	case reflect.String:
+		return &object.String{Value: v.String()}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: v.Int()}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return object.NewBigInt(new(big.Int).SetUint64(v.Uint())), nil
+
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: v.Float()}, nil
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return evaluator.NULL, nil
+		}
+
+		return toObject(v.Elem())
+
+	case reflect.Slice, reflect.Array:
+		elements := make([]object.Object, v.Len())
+		for i := range elements {
+			el, err := toObject(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = el
+		}
+
+		return &object.Array{Elements: elements}, nil
+
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return lessKey(keys[i], keys[j])
+		})
+
+		hash := object.NewHash()
+		for _, k := range keys {
+			key, err := toObject(k)
+			if err != nil {
+				return nil, err
+			}
+
+			hashable, ok := key.(object.Hashable)
+			if !ok {
+				return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+			}
+
+			val, err := toObject(v.MapIndex(k))
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(hashable, val)
+		}
+
+		return hash, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+// lessKey Orders map keys, numbers numerically and anything else by its printed form
+func lessKey(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Interface {
+		a = a.Elem()
+	}
+	if b.Kind() == reflect.Interface {
+		b = b.Elem()
+	}
+
+	switch {
+	case a.CanInt() && b.CanInt():
+		return a.Int() < b.Int()
+	case a.CanUint() && b.CanUint():
+		return a.Uint() < b.Uint()
+	case a.CanFloat() && b.CanFloat():
+		return a.Float() < b.Float()
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b)) < 0
+}
@@ -0,0 +1,148 @@
+package mi
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/seailly/mi/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpreter_Register(t *testing.T) {
+	interp := New()
+
+	register := func(name string, fn interface{}) {
+		require.NoError(t, interp.Register(name, fn))
+	}
+
+	register("sha", func(s string) string {
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(s)))[:8]
+	})
+	register("add", func(a int, b int64) int64 { return int64(a) + b })
+	register("half", func(f float64) float64 { return f / 2 })
+	register("not", func(b bool) bool { return !b })
+	register("sum", func(xs ...int) int {
+		total := 0
+		for _, x := range xs {
+			total += x
+		}
+		return total
+	})
+	register("words", func(s string) []string { return strings.Fields(s) })
+	register("count", func(words []string) map[string]int {
+		counts := map[string]int{}
+		for _, w := range words {
+			counts[w]++
+		}
+		return counts
+	})
+	register("total", func(m map[string][]float64) float64 {
+		total := 0.0
+		for _, xs := range m {
+			for _, x := range xs {
+				total += x
+			}
+		}
+		return total
+	})
+	register("describe", func(v interface{}) string { return fmt.Sprintf("%T %v", v, v) })
+	register("same", func(obj object.Object) object.Object { return obj })
+	register("parse", func(s string) (int, error) {
+		if s == "" {
+			return 0, errors.New("empty input")
+		}
+		return len(s), nil
+	})
+	register("nothing", func() {})
+	register("max", func() uint64 { return 1<<64 - 1 })
+	register("double", func(n *big.Int) *big.Int { return n.Lsh(n, 1) })
+	register("small", func(n int8) int8 { return n })
+	register("natural", func(n uint) uint { return n })
+	register("single", func(f float32) float32 { return f })
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`sha("mi")`, `"` + fmt.Sprintf("%x", sha256.Sum256([]byte("mi")))[:8] + `"`},
+		{"add(1, 2)", "3"},
+		{"half(3)", "1.5"},
+		{"half(3.0)", "1.5"},
+		{"not(true)", "false"},
+		{"not(false) == true", "true"},
+		{"sum()", "0"},
+		{"sum(1, 2, 3)", "6"},
+		{`words(" a b  c ")`, `["a", "b", "c"]`},
+		{`count(["b", "a", "b"])`, `{"a": 1, "b": 2}`},
+		{`total({"a": [1, 2.5], "b": []})`, "3.5"},
+		{"describe(1)", `"int64 1"`},
+		{`describe([1, "a", nothing()])`, `"[]interface {} [1 a <nil>]"`},
+		{`same([1, 2])`, "[1, 2]"},
+		{`parse("abc")`, "3"},
+		{"nothing()", "null"},
+		{"max()", "18446744073709551615"},
+		{"double(max())", "36893488147419103230"},
+		{"small(-128)", "-128"},
+		{"single(2.0 ** 127)", "1.7014118346046923e+38"},
+		{"mut f = sha; f == sha", "true"},
+	}
+
+	for _, tt := range tests {
+		result, err := interp.Eval(context.Background(), tt.input)
+		require.NoError(t, err, tt.input)
+		require.Equal(t, tt.expected, result.Inspect(), tt.input)
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"sha(1)", "argument 1 to sha: cannot use INTEGER as string"},
+		{`add(1, "2")`, "argument 2 to add: cannot use STRING as int64"},
+		{"add(1)", "wrong number of arguments to add: got 1, want 2"},
+		{`sum(1, "2")`, "argument 2 to sum: cannot use STRING as int"},
+		{"half(nothing())", "argument 1 to half: cannot use NULL as float64"},
+		{`count(["a", 1])`, "argument 1 to count at [1]: cannot use INTEGER as string"},
+		{`total({"a": [1, true]})`, `argument 1 to total at ["a"][1]: cannot use BOOLEAN as float64`},
+		{`total({1: []})`, "argument 1 to total at key 1: cannot use INTEGER as string"},
+		{"describe(sha)", "argument 1 to describe: cannot use BUILTIN as interface {}"},
+		{`parse("")`, "parse: empty input"},
+		{"small(128)", "argument 1 to small: 128 overflows int8"},
+		{"natural(-1)", "argument 1 to natural: -1 overflows uint"},
+		{"small(max())", "argument 1 to small: 18446744073709551615 overflows int8"},
+		{"single(1e39)", "argument 1 to single: 1e+39 overflows float32"},
+		{"single(-1e39)", "argument 1 to single: -1e+39 overflows float32"},
+	}
+
+	for _, tt := range errorTests {
+		_, err := interp.Eval(context.Background(), tt.input)
+
+		var runtimeErr *RuntimeError
+		require.ErrorAs(t, err, &runtimeErr, tt.input)
+		require.Equal(t, tt.expected, runtimeErr.Err.Message, tt.input)
+		require.True(t, runtimeErr.Err.Pos.IsValid(), tt.input)
+	}
+}
+
+func TestInterpreter_RegisterInvalid(t *testing.T) {
+	tests := []struct {
+		fn       interface{}
+		expected string
+	}{
+		{42, "mi: cannot register f: int is not a function"},
+		{(func())(nil), "mi: cannot register f: func() is not a function"},
+		{func(chan int) {}, "mi: cannot register f: unsupported parameter type chan int"},
+		{func() (int, int) { return 0, 0 }, "mi: cannot register f: functions return at most one value and an error"},
+		{func() *int { return nil }, "mi: cannot register f: unsupported result type *int"},
+	}
+
+	for _, tt := range tests {
+		err := New().Register("f", tt.fn)
+		require.EqualError(t, err, tt.expected)
+	}
+}
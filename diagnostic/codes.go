@@ -0,0 +1,36 @@
+package diagnostic
+
+// Lexical errors
+const (
+	IllegalCharacter    = "E0001" // a character that can't start any token
+	InvalidEscape       = "E0002" // unknown \x escape in a string
+	InvalidUnicode      = "E0003" // malformed \u{...} escape or code point
+	UnterminatedString  = "E0004" // string literal without a closing quote
+	MalformedNumber     = "E0005" // number literal with missing or invalid digits
+	UnterminatedComment = "E0006" // block comment without a closing */
+	InvalidUTF8         = "E0007" // bytes that aren't valid UTF-8
+	ReadFailed          = "E0008" // the input couldn't be read to the end
+)
+
+// Syntax errors
+const (
+	UnexpectedToken    = "E0100" // a specific token was expected
+	ExpectedExpression = "E0101" // the token can't start an expression
+	InvalidInteger     = "E0102" // integer literal out of range
+	UnclosedBlock      = "E0103" // block missing its closing }
+	OutsideLoop        = "E0104" // break or continue used outside of a loop
+	InvalidAssignment  = "E0105" // left side of = is not a name or index
+	InvalidFloat       = "E0107" // float literal out of range
+)
+
+// Name errors, reported by the resolver
+const (
+	UndefinedName       = "E0200" // no variable or builtin of that name is visible
+	UseBeforeDefinition = "E0201" // a variable used earlier in its scope than its declaration
+	ImmutableBinding    = "E0202" // assignment to or redeclaration of a let binding
+)
+
+// Runtime errors
+const (
+	RuntimeError = "E1000" // raised while evaluating a program
+)
@@ -0,0 +1,113 @@
+package diagnostic
+
+import (
+	"fmt"
+
+	"github.com/seailly/mi/token"
+)
+
+// Severity How serious a diagnostic is
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Note
+)
+
+var severityNames = map[Severity]string{
+	Error:   "error",
+	Warning: "warning",
+	Note:    "note",
+}
+
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// MarshalText Severities are written by name in JSON
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText
+func (s *Severity) UnmarshalText(text []byte) error {
+	for severity, name := range severityNames {
+		if name == string(text) {
+			*s = severity
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown severity %q", text)
+}
+
+// Span A range of source, End is the position immediately after the last character
+type Span struct {
+	Start token.Position `json:"start"`
+	End   token.Position `json:"end"`
+}
+
+// Label A secondary span with a note explaining how it relates to the diagnostic
+type Label struct {
+	Span    Span   `json:"span"`
+	Message string `json:"message"`
+}
+
+// Diagnostic A positioned problem found in a program by the lexer, parser, evaluator or a checker
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"` // eg: E0001, see codes.go
+	Message  string   `json:"message"`
+	Span     Span     `json:"span"` // primary location
+	Labels   []Label  `json:"labels,omitempty"`
+	Hint     string   `json:"hint,omitempty"` // optional suggestion on how to fix the problem
+}
+
+// New Create an error diagnostic spanning start to end
+func New(code string, start token.Position, end token.Position, format string, a ...interface{}) Diagnostic {
+	return Diagnostic{
+		Severity: Error,
+		Code:     code,
+		Message:  fmt.Sprintf(format, a...),
+		Span:     Span{Start: start, End: end},
+	}
+}
+
+// WithLabel Return a copy of d with a secondary label added
+func (d Diagnostic) WithLabel(start token.Position, end token.Position, message string) Diagnostic {
+	labels := make([]Label, len(d.Labels), len(d.Labels)+1)
+	copy(labels, d.Labels)
+	d.Labels = append(labels, Label{Span: Span{Start: start, End: end}, Message: message})
+
+	return d
+}
+
+// WithHint Return a copy of d with a fix hint
+func (d Diagnostic) WithHint(hint string) Diagnostic {
+	d.Hint = hint
+	return d
+}
+
+// String Single line form, file:line:col: message
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Span.Start, d.Message)
+}
+
+// Error Diagnostics can be returned as Go errors
+func (d Diagnostic) Error() string {
+	return d.String()
+}
+
+// Less Orders diagnostics by where they start in the source
+func Less(a Diagnostic, b Diagnostic) bool {
+	if a.Span.Start.Filename != b.Span.Start.Filename {
+		return a.Span.Start.Filename < b.Span.Start.Filename
+	}
+
+	return a.Span.Start.Offset < b.Span.Start.Offset
+}
@@ -0,0 +1,53 @@
+package diagnostic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/seailly/mi/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnostic_String(t *testing.T) {
+	d := New(UnexpectedToken,
+		token.Position{Filename: "main.mi", Offset: 6, Line: 1, Column: 7},
+		token.Position{Filename: "main.mi", Offset: 8, Line: 1, Column: 9},
+		"expected next token to be %s", "=")
+
+	require.Equal(t, Error, d.Severity)
+	require.Equal(t, "main.mi:1:7: expected next token to be =", d.String())
+	require.Equal(t, d.String(), d.Error())
+}
+
+func TestDiagnostic_WithLabelCopies(t *testing.T) {
+	d := New(UnclosedBlock, token.Position{Line: 2, Column: 1}, token.Position{Line: 2, Column: 1}, "unclosed")
+	labelled := d.WithLabel(token.Position{Line: 1, Column: 1}, token.Position{Line: 1, Column: 2}, "opened here")
+
+	require.Empty(t, d.Labels)
+	require.Len(t, labelled.Labels, 1)
+	require.Equal(t, "opened here", labelled.Labels[0].Message)
+}
+
+func TestDiagnostic_JSON(t *testing.T) {
+	d := New(InvalidEscape,
+		token.Position{Filename: "main.mi", Offset: 2, Line: 1, Column: 3},
+		token.Position{Filename: "main.mi", Offset: 4, Line: 1, Column: 5},
+		"invalid escape sequence \\q").WithHint("remove the backslash")
+
+	data, err := json.Marshal(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"severity": "error",
+		"code": "E0002",
+		"message": "invalid escape sequence \\q",
+		"span": {
+			"start": {"Filename": "main.mi", "Offset": 2, "Line": 1, "Column": 3},
+			"end": {"Filename": "main.mi", "Offset": 4, "Line": 1, "Column": 5}
+		},
+		"hint": "remove the backslash"
+	}`, string(data))
+
+	var decoded Diagnostic
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, d, decoded)
+}
@@ -0,0 +1,140 @@
+package diagnostic
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// annotation A span to underline beneath its line of source
+type annotation struct {
+	span    Span
+	mark    string // ^ for the primary span, - for labels
+	message string
+}
+
+// Render Write d in the style of rustc, quoting the lines of source it refers to
+// and underlining the spans with carets. source is the text the positions refer to,
+// when it is empty only the header and location are written
+func Render(w io.Writer, d Diagnostic, source string) error {
+	var out strings.Builder
+
+	out.WriteString(d.Severity.String())
+	if d.Code != "" {
+		out.WriteString("[" + d.Code + "]")
+	}
+	out.WriteString(": " + d.Message + "\n")
+
+	annotations := []annotation{{span: d.Span, mark: "^"}}
+	for _, label := range d.Labels {
+		annotations = append(annotations, annotation{span: label.Span, mark: "-", message: label.Message})
+	}
+	sort.SliceStable(annotations, func(i, j int) bool {
+		return annotations[i].span.Start.Line < annotations[j].span.Start.Line
+	})
+
+	lines := splitLines(source)
+	width := len(strconv.Itoa(annotations[len(annotations)-1].span.Start.Line))
+	gutter := strings.Repeat(" ", width) + " |"
+
+	out.WriteString(fmt.Sprintf("%s--> %s\n", strings.Repeat(" ", width), d.Span.Start))
+
+	if len(lines) > 0 {
+		out.WriteString(gutter + "\n")
+
+		previous := 0
+		for _, a := range annotations {
+			line := a.span.Start.Line
+			if line < 1 || line > len(lines) {
+				continue
+			}
+
+			if line != previous {
+				if previous != 0 && line > previous+1 {
+					out.WriteString("...\n")
+				}
+
+				out.WriteString(fmt.Sprintf("%*d | %s\n", width, line, lines[line-1].text))
+				previous = line
+			}
+
+			out.WriteString(gutter + " " + underline(lines[line-1], a) + "\n")
+		}
+	}
+
+	if d.Hint != "" {
+		out.WriteString(gutter + "\n")
+		out.WriteString(fmt.Sprintf("%s = help: %s\n", strings.Repeat(" ", width), d.Hint))
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// sourceLine A line of source and the offset it starts at
+type sourceLine struct {
+	text   string
+	offset int
+}
+
+func splitLines(source string) []sourceLine {
+	if source == "" {
+		return nil
+	}
+
+	var lines []sourceLine
+
+	offset := 0
+	for _, text := range strings.SplitAfter(source, "\n") {
+		lines = append(lines, sourceLine{text: strings.TrimRight(text, "\r\n"), offset: offset})
+		offset += len(text)
+	}
+
+	return lines
+}
+
+// underline Marks under the annotated part of line, the padding keeps tabs so the marks line up
+func underline(line sourceLine, a annotation) string {
+	start := clamp(a.span.Start.Offset-line.offset, 0, len(line.text))
+
+	end := len(line.text)
+	if a.span.End.Line == a.span.Start.Line {
+		end = clamp(a.span.End.Offset-line.offset, start, len(line.text))
+	}
+
+	var padding strings.Builder
+	for _, r := range line.text[:start] {
+		if r == '\t' {
+			padding.WriteRune('\t')
+		} else {
+			padding.WriteRune(' ')
+		}
+	}
+
+	count := utf8.RuneCountInString(line.text[start:end])
+	if count < 1 {
+		count = 1
+	}
+
+	marks := padding.String() + strings.Repeat(a.mark, count)
+	if a.message != "" {
+		marks += " " + a.message
+	}
+
+	return marks
+}
+
+func clamp(n int, low int, high int) int {
+	if n < low {
+		return low
+	}
+
+	if n > high {
+		return high
+	}
+
+	return n
+}
@@ -0,0 +1,76 @@
+package diagnostic
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/seailly/mi/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	source := "mut f = fn(x) {\n  x + 1;\n\n\tmut y = \"é\" + 2\n"
+
+	tests := []struct {
+		diagnostic Diagnostic
+		expected   string
+	}{
+		{
+			New(UnexpectedToken,
+				token.Position{Filename: "main.mi", Offset: 20, Line: 2, Column: 5},
+				token.Position{Filename: "main.mi", Offset: 21, Line: 2, Column: 6},
+				"bad operator"),
+			`error[E0100]: bad operator
+ --> main.mi:2:5
+  |
+2 |   x + 1;
+  |     ^
+`,
+		},
+		{
+			New(UnclosedBlock,
+				token.Position{Offset: 44, Line: 5, Column: 1},
+				token.Position{Offset: 44, Line: 5, Column: 1},
+				"unclosed block").
+				WithLabel(token.Position{Offset: 14, Line: 1, Column: 15}, token.Position{Offset: 15, Line: 1, Column: 16}, "opened here").
+				WithHint("add a }"),
+			`error[E0103]: unclosed block
+ --> 5:1
+  |
+1 | mut f = fn(x) {
+  |               - opened here
+...
+5 | 
+  | ^
+  |
+  = help: add a }
+`,
+		},
+		{
+			New(RuntimeError,
+				token.Position{Offset: 35, Line: 4, Column: 10},
+				token.Position{Offset: 43, Line: 4, Column: 17},
+				"type mismatch"),
+			"error[E1000]: type mismatch\n --> 4:10\n  |\n4 | \tmut y = \"é\" + 2\n  | \t        ^^^^^^^\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+
+		require.NoError(t, Render(&out, tt.diagnostic, source))
+		require.Equal(t, tt.expected, out.String())
+	}
+}
+
+func TestRender_WithoutSource(t *testing.T) {
+	d := Diagnostic{
+		Severity: Warning,
+		Message:  "unused",
+		Span:     Span{Start: token.Position{Line: 12, Column: 3}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, Render(&out, d, ""))
+	require.Equal(t, "warning: unused\n  --> 12:3\n", out.String())
+}
@@ -0,0 +1,52 @@
+package mi
+
+import (
+	"fmt"
+
+	"github.com/seailly/mi/diagnostic"
+	"github.com/seailly/mi/object"
+)
+
+// ParseError Source that failed to parse or refers to undefined names
+type ParseError struct {
+	Source      string
+	Diagnostics []diagnostic.Diagnostic // at least one, in source order
+}
+
+func (e *ParseError) Error() string {
+	msg := "mi: " + e.Diagnostics[0].String()
+	if more := len(e.Diagnostics) - 1; more > 0 {
+		msg += fmt.Sprintf(" (and %d more errors)", more)
+	}
+
+	return msg
+}
+
+// RuntimeError An error raised while a program was running
+type RuntimeError struct {
+	Source string
+	Err    *object.Error
+}
+
+func (e *RuntimeError) Error() string {
+	if e.Err.Pos.IsValid() {
+		return "mi: " + e.Err.Pos.String() + ": " + e.Err.Message
+	}
+
+	return "mi: " + e.Err.Message
+}
+
+// Unwrap The Go error behind the error, such as one returned by a registered function or ErrStepLimit
+func (e *RuntimeError) Unwrap() error {
+	return e.Err.Cause
+}
+
+// Diagnostic The error as a positioned diagnostic, which diagnostic.Render can show against Source
+func (e *RuntimeError) Diagnostic() diagnostic.Diagnostic {
+	return e.Err.Diagnostic()
+}
+
+// StackTrace The calls the error unwound through, see object.Error.StackTrace
+func (e *RuntimeError) StackTrace() string {
+	return e.Err.StackTrace()
+}
@@ -0,0 +1,125 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/seailly/mi/ast"
+	"github.com/seailly/mi/object"
+)
+
+// evalAssignExpression Evaluates to the assigned value
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment, b *Budget) object.Object {
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		return evalIdentifierAssignment(node, target, env, b)
+	case *ast.IndexExpression:
+		return evalIndexAssignment(node, target, env, b)
+	default:
+		return locate(newError("cannot assign to %s", node.Target.String()), node.Pos(), node.End())
+	}
+}
+
+// evalIdentifierAssignment Updates the nearest enclosing binding, so closures can mutate captured variables
+func evalIdentifierAssignment(
+	node *ast.AssignExpression,
+	target *ast.Identifier,
+	env *object.Environment,
+	b *Budget,
+) object.Object {
+	if target.Resolution != ast.Variable {
+		return locate(unresolved(target), target.Pos(), target.End())
+	}
+
+	current := Assignable(env, target.Depth, target.Slot, target.Value)
+	if isError(current) {
+		return locate(current, target.Pos(), target.End())
+	}
+
+	val := evalAssignedValue(node, current, env, b)
+	if isAbrupt(val) {
+		return val
+	}
+
+	return locate(Assign(env, target.Depth, target.Slot, target.Value, val), target.Pos(), target.End())
+}
+
+func assignmentError(err error, name string) *object.Error {
+	return newError("cannot assign to %s: %s", err, name)
+}
+
+// evalIndexAssignment Updates an array element or hash entry in place
+func evalIndexAssignment(
+	node *ast.AssignExpression,
+	target *ast.IndexExpression,
+	env *object.Environment,
+	b *Budget,
+) object.Object {
+	left := eval(target.Left, env, b)
+	if isAbrupt(left) {
+		return left
+	}
+
+	index := eval(target.Index, env, b)
+	if isAbrupt(index) {
+		return index
+	}
+
+	var current object.Object
+	if node.Operator != "=" {
+		current = evalIndexExpression(left, index)
+		if isError(current) {
+			return locate(current, target.Token.Pos, target.End())
+		}
+	}
+
+	val := evalAssignedValue(node, current, env, b)
+	if isAbrupt(val) {
+		return val
+	}
+
+	return locate(setIndex(left, index, val), target.Token.Pos, target.End())
+}
+
+// evalAssignedValue Evaluates the right hand side, combining it with current for compound operators
+func evalAssignedValue(
+	node *ast.AssignExpression,
+	current object.Object,
+	env *object.Environment,
+	b *Budget,
+) object.Object {
+	val := eval(node.Value, env, b)
+	if isAbrupt(val) || node.Operator == "=" {
+		return val
+	}
+
+	operator := strings.TrimSuffix(node.Operator, "=")
+	return locate(evalInfixExpression(operator, current, val), node.Token.Pos, node.Token.End)
+}
+
+func setIndex(left, index, val object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError("array index must be %s, got %s", object.INTEGER_OBJECT, index.Type())
+		}
+
+		pos, ok := arrayPosition(idx.Value, len(left.Elements))
+		if !ok {
+			return newError("index out of range: %d (length %d)", idx.Value, len(left.Elements))
+		}
+
+		left.Elements[pos] = val
+	case *object.Hash:
+		key, err := HashKey(index)
+		if err != nil {
+			return err
+		}
+
+		left.Set(key, val)
+	default:
+		return newError("index assignment not supported: %s", left.Type())
+	}
+
+	return val
+}
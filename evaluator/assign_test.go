@@ -0,0 +1,138 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/seailly/mi/lexer"
+	"github.com/seailly/mi/object"
+	"github.com/seailly/mi/parser"
+	"github.com/seailly/mi/resolver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"mut x = 1; x = 5; x", 5},
+		{"mut x = 1; x = 5", 5},
+		{"mut x = 1; x += 2; x", 3},
+		{"mut x = 10; x -= 4; x", 6},
+		{"mut x = 3; x *= 4; x", 12},
+		{"mut x = 12; x /= 5; x", 2},
+		{"mut a = 1; mut b = 2; a = b = 7; a + b", 14},
+		{"mut x = 1; if (true) { x = 2; } x", 2},
+		{"mut x = 1; mut f = fn() { x = 5; }; f(); x", 5},
+		{"mut x = 1; mut f = fn() { mut x = 2; x = 3; }; f(); x", 1},
+		{"mut counter = fn() { mut n = 0; fn() { n += 1 } }; mut c = counter(); c(); c(); c()", 3},
+		{"mut s = 0; for (i in range(5)) { s += i; } s", 10},
+		{"mut i = 0; while (i < 10) { i += 1; } i", 10},
+		{"mut a = [1, 2, 3]; a[0] = 9; a[0]", 9},
+		{"mut a = [1, 2, 3]; a[-1] *= 10; a[2]", 30},
+		{"mut a = [1, 2, 3]; mut b = a; b[1] = 5; a[1]", 5},
+		{`mut h = {}; h["x"] = 4; h["x"]`, 4},
+		{`mut h = {"x": 1}; h["x"] += 1; h["x"]`, 2},
+		{`mut h = {"a": [1]}; h["a"][0] = 8; h["a"][0]`, 8},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestAssignStrings(t *testing.T) {
+	evaluated := testEval(`mut s = "foo"; s += "bar"; s`)
+
+	str, ok := evaluated.(*object.String)
+	require.True(t, ok)
+	require.Equal(t, "foobar", str.Value)
+}
+
+func TestAssignNamesFunctions(t *testing.T) {
+	evaluated := testEval("mut f = 1; f = fn() { 1 }; f")
+
+	fn, ok := evaluated.(*object.Function)
+	require.True(t, ok)
+	require.Equal(t, "f", fn.Name)
+}
+
+func TestAssignErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+		expectedPos     string
+	}{
+		{"x = 5", "undefined name x", "1:1"},
+		{"mut f = fn() { y += 1 }; f()", "undefined name y", "1:16"},
+		{"len = 5", "undefined name len", "1:1"},
+		{"if (false) { mut x = 1 }; x = 5", "cannot assign to undeclared identifier: x", "1:27"},
+		{"mut x = 1; x += true", "type mismatch: INTEGER + BOOLEAN", "1:14"},
+		{"mut x = 1; x /= 0", "division by zero", "1:14"},
+		{"mut a = [1]; a[1] = 2", "index out of range: 1 (length 1)", "1:15"},
+		{`mut a = [1]; a["x"] = 2`, "array index must be INTEGER, got STRING", "1:15"},
+		{`mut h = {}; h[fn() {}] = 2`, "unusable as hash key: FUNCTION", "1:14"},
+		{`mut h = {}; h["x"] += 1`, "type mismatch: NULL + INTEGER", "1:20"},
+		{`mut s = "ab"; s[0] = "c"`, "index assignment not supported: STRING", "1:16"},
+		{"mut x = 1; x = y", "undefined name y", "1:16"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		require.True(t, ok, tt.input)
+		require.Equal(t, tt.expectedMessage, errObj.Message, tt.input)
+		require.Equal(t, tt.expectedPos, errObj.Pos.String(), tt.input)
+	}
+}
+
+func TestLetStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let a = 5; a;", 5},
+		{"let a = 5 * 5; a;", 25},
+		{"let a = 5; let b = a; b;", 5},
+		{"let a = [1]; a[0] = 2; a[0]", 2},
+		{"let a = 1; mut f = fn() { mut a = 2; a = 3; a }; f() + a", 4},
+		{"let a = 1; mut f = fn(a) { a += 1; a }; f(5)", 6},
+		{"mut a = 1; let a = 2; a", 2},
+		{"let inc = fn(x) { x + 1 }; inc(1)", 2},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestImmutableAcrossEvaluations(t *testing.T) {
+	tests := []struct {
+		lines           []string
+		expectedMessage string
+		expectedPos     string
+	}{
+		// The resolver sees each REPL line on its own, so these are checked again at runtime
+		{[]string{"let a = 1;", "a = 2;"}, "cannot assign to immutable binding: a", "1:1"},
+		{[]string{"let a = 1;", "mut x = 1; if (x == 1) { mut a = 2 }; a"}, "cannot redeclare immutable binding: a", "1:30"},
+		{[]string{"let a = 1;", "let a = 2;"}, "cannot redeclare immutable binding: a", "1:5"},
+		{[]string{"mut a = 1; if (true) { let a = 2 };", "a = 3"}, "cannot assign to immutable binding: a", "1:1"},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+
+		var evaluated object.Object
+
+		for _, line := range tt.lines {
+			program := parser.New(lexer.New(line)).ParseProgram()
+			require.Empty(t, resolver.Resolve(program, env, IsBuiltin), line)
+
+			evaluated = Eval(program, env)
+		}
+
+		errObj, ok := evaluated.(*object.Error)
+		require.True(t, ok, tt.lines)
+		require.Equal(t, tt.expectedMessage, errObj.Message, tt.lines)
+		require.Equal(t, tt.expectedPos, errObj.Pos.String(), tt.lines)
+	}
+}
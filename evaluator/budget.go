@@ -0,0 +1,126 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/seailly/mi/object"
+)
+
+// DefaultMaxDepth Calls allowed on the stack when Limits leaves MaxDepth unset, deep enough for ordinary recursion
+// while staying well within the Go stack
+const DefaultMaxDepth = 10000
+
+// checkInterval Steps between checks of the context and deadline, which are too slow to check on every step
+const checkInterval = 1024
+
+var (
+	// ErrStepLimit The run took more steps than Limits.MaxSteps allows
+	ErrStepLimit = errors.New("step limit exceeded")
+	// ErrDepthLimit The run nested more calls than Limits.MaxDepth allows
+	ErrDepthLimit = errors.New("call depth limit exceeded")
+)
+
+// Limits Bounds on the resources a single run may use. Exceeding one stops the run with an error whose Cause is
+// ErrStepLimit, ErrDepthLimit, or the error of the context when it is cancelled or the timeout expires
+type Limits struct {
+	MaxSteps int64         // nodes the evaluator visits or instructions the vm executes, 0 for no limit
+	MaxDepth int           // nested function calls, 0 for DefaultMaxDepth
+	Timeout  time.Duration // wall clock time, 0 for no limit besides the deadline of the context
+}
+
+// Budget Counts the steps and calls of a run against its limits. A Budget is used by a single run
+type Budget struct {
+	ctx      context.Context
+	limits   Limits
+	deadline time.Time
+	steps    int64
+	next     int64 // step at which the limits are checked next
+	depth    int
+}
+
+// NewBudget A budget for a run that starts now and stops when ctx is done
+func NewBudget(ctx context.Context, limits Limits) *Budget {
+	if limits.MaxDepth <= 0 {
+		limits.MaxDepth = DefaultMaxDepth
+	}
+
+	b := &Budget{ctx: ctx, limits: limits}
+	if limits.Timeout > 0 {
+		b.deadline = time.Now().Add(limits.Timeout)
+	}
+	b.schedule()
+
+	return b
+}
+
+// Step Count a step, returning an error once the run has exhausted its steps, been cancelled or run out of time.
+// Most steps only count, so it is cheap enough to inline
+func (b *Budget) Step() *object.Error {
+	b.steps++
+	if b.steps < b.next {
+		return nil
+	}
+
+	return b.check()
+}
+
+// check The limits at a step where they are due
+func (b *Budget) check() *object.Error {
+	if b.limits.MaxSteps > 0 && b.steps > b.limits.MaxSteps {
+		return exceeded(ErrStepLimit)
+	}
+
+	if b.steps%checkInterval == 0 {
+		if err := b.Check(); err != nil {
+			return err
+		}
+	}
+
+	b.schedule()
+
+	return nil
+}
+
+// schedule Set the step of the next check, the next multiple of checkInterval or the step past MaxSteps
+func (b *Budget) schedule() {
+	b.next = (b.steps/checkInterval + 1) * checkInterval
+	if b.limits.MaxSteps > 0 && b.limits.MaxSteps+1 < b.next {
+		b.next = b.limits.MaxSteps + 1
+	}
+}
+
+// Check Whether the run has been cancelled or run out of time
+func (b *Budget) Check() *object.Error {
+	if err := b.ctx.Err(); err != nil {
+		return exceeded(err)
+	}
+
+	if !b.deadline.IsZero() && time.Now().After(b.deadline) {
+		return exceeded(context.DeadlineExceeded)
+	}
+
+	return nil
+}
+
+// Enter Count a call, returning an error when it would nest deeper than the limit. Each successful Enter must be
+// matched by a Leave
+func (b *Budget) Enter() *object.Error {
+	if b.depth >= b.limits.MaxDepth {
+		return exceeded(ErrDepthLimit)
+	}
+
+	b.depth++
+	return nil
+}
+
+// Leave Count the return of a call
+func (b *Budget) Leave() {
+	b.depth--
+}
+
+// exceeded
+func exceeded(cause error) *object.Error {
+	return &object.Error{Message: cause.Error(), Cause: cause}
+}
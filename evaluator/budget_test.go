@@ -0,0 +1,60 @@
+package evaluator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/seailly/mi/lexer"
+	"github.com/seailly/mi/object"
+	"github.com/seailly/mi/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvalContext(ctx context.Context, input string, limits Limits) object.Object {
+	program := parser.New(lexer.New(input)).ParseProgram()
+
+	return EvalContext(ctx, program, object.NewEnvironment(), limits)
+}
+
+func TestEvalContextLimits(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		input  string
+		ctx    context.Context
+		limits Limits
+		cause  error
+		pos    string
+	}{
+		{"while (true) { }", context.Background(), Limits{MaxSteps: 1000}, ErrStepLimit, "1:8"},
+		{"while (true) { }", context.Background(), Limits{Timeout: time.Millisecond}, context.DeadlineExceeded, ""},
+		{"1", canceled, Limits{}, context.Canceled, ""},
+		{"fn(f) { f(f) }(fn(f) { f(f) })", context.Background(), Limits{}, ErrDepthLimit, "1:24"},
+		{"mut f = fn(n) { f(n + 1) }; f(0)", context.Background(), Limits{MaxDepth: 50}, ErrDepthLimit, "1:17"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEvalContext(tt.ctx, tt.input, tt.limits).(*object.Error)
+		require.True(t, ok, tt.input)
+		require.ErrorIs(t, errObj.Cause, tt.cause, tt.input)
+		require.Equal(t, tt.cause.Error(), errObj.Message, tt.input)
+
+		if tt.pos != "" {
+			require.Equal(t, tt.pos, errObj.Pos.String(), tt.input)
+		}
+	}
+}
+
+func TestEvalContextWithinLimits(t *testing.T) {
+	input := "mut count = fn(n) { if (n == 0) { return 0 }; 1 + count(n - 1) }; count(100)"
+
+	result := testEvalContext(context.Background(), input, Limits{MaxSteps: 10000, MaxDepth: 101})
+	testIntegerObject(t, result, 100)
+
+	errObj, ok := testEvalContext(context.Background(), input, Limits{MaxDepth: 100}).(*object.Error)
+	require.True(t, ok)
+	require.ErrorIs(t, errObj.Cause, ErrDepthLimit)
+	require.Len(t, errObj.Trace, 100)
+}
@@ -0,0 +1,272 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/seailly/mi/object"
+)
+
+// builtins Looked up after the environment, so scripts can shadow them
+var builtins = map[string]*object.Builtin{}
+
+func init() {
+	register := func(name string, fn object.BuiltinFunction) {
+		builtins[name] = &object.Builtin{Name: name, Fn: fn}
+	}
+
+	register("len", builtinLen)
+	register("puts", builtinPuts)
+	register("print", builtinPrint)
+	register("first", builtinFirst)
+	register("last", builtinLast)
+	register("rest", builtinRest)
+	register("push", builtinPush)
+	register("type", builtinType)
+	register("str", builtinStr)
+	register("int", builtinInt)
+	register("float", builtinFloat)
+	register("range", builtinRange)
+}
+
+// wrongArguments
+func wrongArguments(name string, got int, want int) *object.Error {
+	return newError("wrong number of arguments to %s: got %d, want %d", name, got, want)
+}
+
+// builtinLen Length of a string in characters, or the number of elements in an array or hash
+func builtinLen(_ *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongArguments("len", len(args), 1)
+	}
+
+	switch arg := args[0].(type) {
+	case *object.String:
+		return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+	case *object.Array:
+		return &object.Integer{Value: int64(len(arg.Elements))}
+	case *object.Hash:
+		return &object.Integer{Value: int64(arg.Len())}
+	case *object.Range:
+		return &object.Integer{Value: arg.Len()}
+	default:
+		return newError("argument to len not supported, got %s", args[0].Type())
+	}
+}
+
+// builtinPuts Print the arguments separated by spaces, followed by a newline, to the output of env
+func builtinPuts(env *object.Environment, args ...object.Object) object.Object {
+	fmt.Fprintln(env.Output(), joinArgs(args))
+	return NULL
+}
+
+// builtinPrint Print the arguments separated by spaces to the output of env
+func builtinPrint(env *object.Environment, args ...object.Object) object.Object {
+	fmt.Fprint(env.Output(), joinArgs(args))
+	return NULL
+}
+
+// joinArgs Strings are printed without quotes
+func joinArgs(args []object.Object) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = toString(arg)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func builtinFirst(_ *object.Environment, args ...object.Object) object.Object {
+	array, err := arrayArgument("first", args)
+	if err != nil {
+		return err
+	}
+
+	if len(array.Elements) == 0 {
+		return NULL
+	}
+
+	return array.Elements[0]
+}
+
+func builtinLast(_ *object.Environment, args ...object.Object) object.Object {
+	array, err := arrayArgument("last", args)
+	if err != nil {
+		return err
+	}
+
+	if len(array.Elements) == 0 {
+		return NULL
+	}
+
+	return array.Elements[len(array.Elements)-1]
+}
+
+// builtinRest A new array holding every element but the first
+func builtinRest(_ *object.Environment, args ...object.Object) object.Object {
+	array, err := arrayArgument("rest", args)
+	if err != nil {
+		return err
+	}
+
+	if len(array.Elements) == 0 {
+		return NULL
+	}
+
+	elements := make([]object.Object, len(array.Elements)-1)
+	copy(elements, array.Elements[1:])
+
+	return &object.Array{Elements: elements}
+}
+
+// builtinPush A new array with the second argument appended, the original is left untouched
+func builtinPush(_ *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return wrongArguments("push", len(args), 2)
+	}
+
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("argument to push must be %s, got %s", object.ARRAY_OBJECT, args[0].Type())
+	}
+
+	elements := make([]object.Object, len(array.Elements), len(array.Elements)+1)
+	copy(elements, array.Elements)
+
+	return &object.Array{Elements: append(elements, args[1])}
+}
+
+// arrayArgument Checks a builtin was called with a single array
+func arrayArgument(name string, args []object.Object) (*object.Array, *object.Error) {
+	if len(args) != 1 {
+		return nil, wrongArguments(name, len(args), 1)
+	}
+
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		return nil, newError("argument to %s must be %s, got %s", name, object.ARRAY_OBJECT, args[0].Type())
+	}
+
+	return array, nil
+}
+
+// builtinType Name of the argument's type, eg: "INTEGER"
+func builtinType(_ *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongArguments("type", len(args), 1)
+	}
+
+	return &object.String{Value: string(args[0].Type())}
+}
+
+// builtinStr Convert the argument to a string
+func builtinStr(_ *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongArguments("str", len(args), 1)
+	}
+
+	return &object.String{Value: toString(args[0])}
+}
+
+// toString Same as Inspect, except strings are left unquoted
+func toString(obj object.Object) string {
+	if str, ok := obj.(*object.String); ok {
+		return str.Value
+	}
+
+	return obj.Inspect()
+}
+
+// builtinInt Convert a string, boolean or float to an integer, floats are truncated toward zero
+func builtinInt(_ *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongArguments("int", len(args), 1)
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Integer, *object.BigInt:
+		return arg
+	case *object.Float:
+		if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+			return newError("cannot convert %s to integer", arg.Inspect())
+		}
+		truncated, _ := big.NewFloat(arg.Value).Int(nil)
+		return object.NewBigInt(truncated)
+	case *object.Boolean:
+		if arg.Value {
+			return &object.Integer{Value: 1}
+		}
+		return &object.Integer{Value: 0}
+	case *object.String:
+		value, ok := new(big.Int).SetString(strings.TrimSpace(arg.Value), 10)
+		if !ok {
+			return newError("could not parse %q as integer", arg.Value)
+		}
+		return object.NewBigInt(value)
+	default:
+		return newError("argument to int not supported, got %s", args[0].Type())
+	}
+}
+
+// builtinFloat Convert a string, boolean or integer to a float
+func builtinFloat(_ *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongArguments("float", len(args), 1)
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Float:
+		return arg
+	case *object.Integer, *object.BigInt:
+		return &object.Float{Value: floatValue(arg)}
+	case *object.Boolean:
+		if arg.Value {
+			return &object.Float{Value: 1}
+		}
+		return &object.Float{Value: 0}
+	case *object.String:
+		value, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+		if err != nil {
+			return newError("could not parse %q as float", arg.Value)
+		}
+		return &object.Float{Value: value}
+	default:
+		return newError("argument to float not supported, got %s", args[0].Type())
+	}
+}
+
+// builtinRange range(stop), range(start, stop) or range(start, stop, step)
+func builtinRange(_ *object.Environment, args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 3 {
+		return newError("wrong number of arguments to range: got %d, want 1 to 3", len(args))
+	}
+
+	bounds := make([]int64, len(args))
+	for i, arg := range args {
+		integer, ok := arg.(*object.Integer)
+		if !ok {
+			return newError("arguments to range must be %s, got %s", object.INTEGER_OBJECT, arg.Type())
+		}
+		bounds[i] = integer.Value
+	}
+
+	r := &object.Range{Step: 1}
+	switch len(bounds) {
+	case 1:
+		r.Stop = bounds[0]
+	case 2:
+		r.Start, r.Stop = bounds[0], bounds[1]
+	case 3:
+		r.Start, r.Stop, r.Step = bounds[0], bounds[1], bounds[2]
+	}
+
+	if r.Step == 0 {
+		return newError("range step must not be zero")
+	}
+
+	return r
+}
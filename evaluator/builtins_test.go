@@ -0,0 +1,135 @@
+package evaluator
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/seailly/mi/lexer"
+	"github.com/seailly/mi/object"
+	"github.com/seailly/mi/parser"
+	"github.com/seailly/mi/resolver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`len("")`, 0},
+		{`len("four")`, 4},
+		{`len("hello world")`, 11},
+		{`len("café")`, 4},
+		{`len([1, 2, 3])`, 3},
+		{`len([])`, 0},
+		{`len({"a": 1, "b": 2})`, 2},
+		{`len(1)`, "argument to len not supported, got INTEGER"},
+		{`len("one", "two")`, "wrong number of arguments to len: got 2, want 1"},
+		{`first([1, 2, 3])`, 1},
+		{`first([])`, nil},
+		{`first(1)`, "argument to first must be ARRAY, got INTEGER"},
+		{`last([1, 2, 3])`, 3},
+		{`last([])`, nil},
+		{`last(1)`, "argument to last must be ARRAY, got INTEGER"},
+		{`rest([1, 2, 3])`, []int64{2, 3}},
+		{`rest([1])`, []int64{}},
+		{`rest([])`, nil},
+		{`push([], 1)`, []int64{1}},
+		{`mut a = [1]; push(a, 2); a`, []int64{1}},
+		{`push(1, 1)`, "argument to push must be ARRAY, got INTEGER"},
+		{`push([])`, "wrong number of arguments to push: got 1, want 2"},
+		{`type(1)`, "INTEGER"},
+		{`type("a")`, "STRING"},
+		{`type([])`, "ARRAY"},
+		{`type(len)`, "BUILTIN"},
+		{`str(12)`, "12"},
+		{`str("a")`, "a"},
+		{`str([1, "a"])`, `[1, "a"]`},
+		{`int("42")`, 42},
+		{`int(" -7 ")`, -7},
+		{`int(true)`, 1},
+		{`int(false)`, 0},
+		{`int(5)`, 5},
+		{`int("abc")`, `could not parse "abc" as integer`},
+		{`int([])`, "argument to int not supported, got ARRAY"},
+		{`int(2.9)`, 2},
+		{`int(-2.9)`, -2},
+		{`int(1e19)`, "10000000000000000000"},
+		{`int(-1e19)`, "-10000000000000000000"},
+		{`int(float("inf"))`, "cannot convert +Inf to integer"},
+		{`int("123456789012345678901234567890")`, "123456789012345678901234567890"},
+		{`int(2 ** 64)`, "18446744073709551616"},
+		{`float(2 ** 64)`, 18446744073709551616.0},
+		{`type(2 ** 64)`, "BIGINT"},
+		{`str(2 ** 64)`, "18446744073709551616"},
+		{`int(float("nan"))`, "cannot convert NaN to integer"},
+		{`float(3)`, 3.0},
+		{`float(1.5)`, 1.5},
+		{`float(" 2.5 ")`, 2.5},
+		{`float("1e-3")`, 0.001},
+		{`float(true)`, 1.0},
+		{`float("abc")`, `could not parse "abc" as float`},
+		{`float([])`, "argument to float not supported, got ARRAY"},
+		{`float()`, "wrong number of arguments to float: got 0, want 1"},
+		{`str(2.0)`, "2.0"},
+		{`type(1.5)`, "FLOAT"},
+		{`puts("hello", 1)`, nil},
+		{`puts(fn() {}())`, nil},
+		{`type(if (true) {})`, "NULL"},
+		{`len(fn() {}())`, "argument to len not supported, got NULL"},
+		{`mut len = fn(x) { 42 }; len("a")`, 42},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case nil:
+			require.Equal(t, NULL, evaluated, tt.input)
+		case string:
+			switch result := evaluated.(type) {
+			case *object.String:
+				require.Equal(t, expected, result.Value, tt.input)
+			case *object.Error:
+				require.Equal(t, expected, result.Message, tt.input)
+			case *object.BigInt:
+				require.Equal(t, expected, result.Inspect(), tt.input)
+			default:
+				t.Fatalf("%s: unexpected %T (%+v)", tt.input, evaluated, evaluated)
+			}
+		case []int64:
+			array, ok := evaluated.(*object.Array)
+			require.True(t, ok, tt.input)
+			require.Len(t, array.Elements, len(expected))
+
+			for i, el := range expected {
+				testIntegerObject(t, array.Elements[i], el)
+			}
+		}
+	}
+}
+
+func TestBuiltinOutput(t *testing.T) {
+	var out bytes.Buffer
+
+	env := object.NewEnvironment()
+	env.SetOutput(&out)
+
+	program := parser.New(lexer.New(`puts("a", 1, [2, "c"]); print(1.5, "d"); for (x in [1]) { puts() } puts(fn() {}())`)).ParseProgram()
+	require.Empty(t, resolver.Resolve(program, env, IsBuiltin))
+
+	Eval(program, env)
+	require.Equal(t, "a 1 [2, \"c\"]\n1.5 d\nnull\n", out.String())
+}
+
+func TestBuiltinErrorPosition(t *testing.T) {
+	errObj, ok := testEval(`mut a = 1;
+len(a)`).(*object.Error)
+
+	require.True(t, ok)
+	require.Equal(t, "2:1", errObj.Pos.String())
+}
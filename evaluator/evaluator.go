@@ -1,10 +1,16 @@
 package evaluator
 
 import (
+	"context"
 	"fmt"
+	"math"
+	"math/big"
+	"strings"
 
 	"github.com/seailly/mi/ast"
 	"github.com/seailly/mi/object"
+	"github.com/seailly/mi/resolver"
+	"github.com/seailly/mi/token"
 )
 
 var (
@@ -13,62 +19,108 @@ var (
 	FALSE = &object.Boolean{Value: false}
 )
 
+// Eval Evaluate node in env. A program not yet resolved is first resolved against env with the resolver package,
+// returning its first diagnostic as an error, other nodes must already be resolved. A Go panic raised while
+// evaluating is recovered and returned as an error, so a bad script can't take down the host application.
+// Calls nest at most DefaultMaxDepth deep, see EvalContext for other limits
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	return EvalContext(context.Background(), node, env, Limits{})
+}
+
+// EvalContext Evaluate node in env like Eval, stopping with an error when ctx is done or a limit is exceeded
+func EvalContext(ctx context.Context, node ast.Node, env *object.Environment, limits Limits) (result object.Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = InternalError(r)
+		}
+	}()
+
+	b := NewBudget(ctx, limits)
+	if err := b.Check(); err != nil {
+		return err
+	}
+
+	if program, ok := node.(*ast.Program); ok && !program.Resolved {
+		if diagnostics := resolver.Resolve(program, env, IsBuiltin); len(diagnostics) != 0 {
+			d := diagnostics[0]
+			return &object.Error{Code: d.Code, Message: d.Message, Pos: d.Span.Start, End: d.Span.End}
+		}
+	}
+
+	return eval(node, env, b)
+}
+
+func eval(node ast.Node, env *object.Environment, b *Budget) object.Object {
+	if err := b.Step(); err != nil {
+		return locate(err, node.Pos(), node.End())
+	}
+
 	switch node := node.(type) {
 	// Statements
 	case *ast.Program:
-		return evalProgram(node, env)
+		return evalProgram(node, env, b)
 
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression, env)
+		return eval(node.Expression, env, b)
 
 	case *ast.BlockStatement:
-		return evalBlockStatement(node, env)
+		return evalBlockStatement(node, env, b)
 
 	case *ast.ReturnStatement:
-		val := Eval(node.ReturnValue, env)
-		if isError(val) {
+		val := eval(node.ReturnValue, env, b)
+		if isAbrupt(val) {
 			return val
 		}
 		return &object.ReturnValue{Value: val}
 
 	// Expressions
 	case *ast.IntegerLiteral:
+		if node.Big != nil {
+			return &object.BigInt{Value: node.Big}
+		}
 		return &object.Integer{Value: node.Value}
 
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
+	case *ast.StringLiteral:
+		return &object.String{Value: node.Value}
+
 	case *ast.PrefixExpression:
-		right := Eval(node.Right, env)
-		if isError(right) {
+		right := eval(node.Right, env, b)
+		if isAbrupt(right) {
 			return right
 		}
-		return evalPrefixExpression(node.Operator, right)
+		return locate(evalPrefixExpression(node.Operator, right), node.Pos(), node.End())
 
 	case *ast.InfixExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
+		left := eval(node.Left, env, b)
+		if isAbrupt(left) {
 			return left
 		}
 
-		right := Eval(node.Right, env)
-		if isError(right) {
+		if result, done := shortCircuit(node.Operator, left); done {
+			return result
+		}
+
+		right := eval(node.Right, env, b)
+		if isAbrupt(right) {
 			return right
 		}
 
-		return evalInfixExpression(node.Operator, left, right)
+		return locate(evalInfixExpression(node.Operator, left, right), node.Token.Pos, node.Token.End)
 
 	case *ast.IfExpression:
-		return evalIfExpression(node, env)
+		return evalIfExpression(node, env, b)
 
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 
 	case *ast.MutStatement:
-		val := Eval(node.Value, env)
-		if isError(val) {
-			return val
-		}
+		return evalDeclaration(node.Name, node.Value, true, env, b)
 
-		env.Set(node.Name.Value, val)
+	case *ast.LetStatement:
+		return evalDeclaration(node.Name, node.Value, false, env, b)
 
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
@@ -76,55 +128,114 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Env: env, Body: body}
+		return &object.Function{Parameters: params, Env: env, Body: body, Slots: node.Slots}
 
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
-		if isError(function) {
+		function := eval(node.Function, env, b)
+		if isAbrupt(function) {
 			return function
 		}
 
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
+		args := evalExpressions(node.Arguments, env, b)
+		if len(args) == 1 && isAbrupt(args[0]) {
 			return args[0]
 		}
 
-		return applyFunction(function, args)
+		return locate(applyFunction(function, args, env, node.Pos(), b), node.Pos(), node.End())
+
+	case *ast.ArrayLiteral:
+		elements := evalExpressions(node.Elements, env, b)
+		if len(elements) == 1 && isAbrupt(elements[0]) {
+			return elements[0]
+		}
+
+		return &object.Array{Elements: elements}
+
+	case *ast.IndexExpression:
+		left := eval(node.Left, env, b)
+		if isAbrupt(left) {
+			return left
+		}
+
+		index := eval(node.Index, env, b)
+		if isAbrupt(index) {
+			return index
+		}
+
+		return locate(evalIndexExpression(left, index), node.Token.Pos, node.End())
+
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env, b)
+
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env, b)
+
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env, b)
+
+	case *ast.ForStatement:
+		return evalForStatement(node, env, b)
+
+	case *ast.BreakStatement:
+		return BREAK
+
+	case *ast.ContinueStatement:
+		return CONTINUE
 	}
 
 	return nil
 }
 
-func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+// evalDeclaration Binds the value of a mut or let statement in the current scope
+func evalDeclaration(
+	name *ast.Identifier,
+	value ast.Expression,
+	mutable bool,
+	env *object.Environment,
+	b *Budget,
+) object.Object {
+	val := eval(value, env, b)
+	if isAbrupt(val) {
+		return val
+	}
+
+	return locate(Declare(env, name.Slot, name.Value, val, mutable), name.Pos(), name.End())
+}
+
+func evalProgram(program *ast.Program, env *object.Environment, b *Budget) object.Object {
 	var result object.Object
 
 	for _, statement := range program.Statements {
-		result = Eval(statement, env)
+		result = eval(statement, env, b)
 
 		switch result := result.(type) {
 		case *object.ReturnValue:
 			return result.Value
 		case *object.Error:
 			return result
+		case *object.Break, *object.Continue:
+			return newError("%s outside of a loop", result.Inspect())
 		}
 	}
 
 	return result
 }
 
-func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+func evalBlockStatement(block *ast.BlockStatement, env *object.Environment, b *Budget) object.Object {
 	var result object.Object
 
 	for _, statement := range block.Statements {
-		result = Eval(statement, env)
-		if result != nil {
-			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJECT || rt == object.ERROR_OBJECT {
-				return result
-			}
+		result = eval(statement, env, b)
+		if isAbrupt(result) {
+			return result
 		}
 	}
 
+	// An empty block, or one ending in a declaration, has no value
+	if result == nil {
+		return NULL
+	}
+
 	return result
 }
 
@@ -139,20 +250,37 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 	}
 }
 
+// evalInfixExpression Numbers compare by value and strings lexically. Any other value, a boolean or null, only
+// equals itself and has no ordering, so comparing it with <, >, <= or >= is an error. && and || combine the
+// truthiness of their operands into a boolean
 func evalInfixExpression(
 	operator string,
 	left object.Object,
 	right object.Object,
 ) object.Object {
 	switch {
+	case operator == "&&":
+		return nativeBoolToBooleanObject(isTruthy(left) && isTruthy(right))
+	case operator == "||":
+		return nativeBoolToBooleanObject(isTruthy(left) || isTruthy(right))
+	case operator == "in":
+		return evalInExpression(left, right)
 	case left.Type() == object.INTEGER_OBJECT && right.Type() == object.INTEGER_OBJECT:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isInteger(left) && isInteger(right):
+		return evalBigIntInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
+	case left.Type() == object.STRING_OBJECT && right.Type() == object.STRING_OBJECT:
+		return evalStringInfixExpression(operator, left, right)
 	case operator == "==":
 		return nativeBoolToBooleanObject(left == right)
 	case operator == "!=":
 		return nativeBoolToBooleanObject(left != right)
 	case left.Type() != right.Type():
 		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	case operator == "<" || operator == ">" || operator == "<=" || operator == ">=":
+		return newError("unordered operands: %s %s %s", left.Type(), operator, right.Type())
 	default:
 		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
@@ -165,19 +293,79 @@ func evalIntegerInfixExpression(
 	leftVal := left.(*object.Integer).Value
 	rightVal := right.(*object.Integer).Value
 
+	// Results that overflow an int64 are computed again as big integers
 	switch operator {
 	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
+		sum := leftVal + rightVal
+		if (rightVal > 0 && sum < leftVal) || (rightVal < 0 && sum > leftVal) {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: sum}
 	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
+		difference := leftVal - rightVal
+		if (rightVal < 0 && difference < leftVal) || (rightVal > 0 && difference > leftVal) {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: difference}
 	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
+		product, ok := multiplyInt64(leftVal, rightVal)
+		if !ok {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: product}
 	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		if leftVal == math.MinInt64 && rightVal == -1 {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
 		return &object.Integer{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		return &object.Integer{Value: leftVal % rightVal}
+	case "**":
+		if result, ok := integerPower(leftVal, rightVal); ok {
+			return result
+		}
+		return evalBigIntInfixExpression(operator, left, right)
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalStringInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	case "==":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "!=":
@@ -201,12 +389,19 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJECT {
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Value == math.MinInt64 {
+			return object.NewBigInt(new(big.Int).Neg(big.NewInt(right.Value)))
+		}
+		return &object.Integer{Value: -right.Value}
+	case *object.BigInt:
+		return object.NewBigInt(new(big.Int).Neg(right.Value))
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
 		return newError("unknown operator: -%s", right.Type())
 	}
-
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
 }
 
 // nativeBoolToBooleanObject Return a reference to boolean object to reduce number created
@@ -218,39 +413,194 @@ func nativeBoolToBooleanObject(input bool) *object.Boolean {
 	return FALSE
 }
 
-func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
-	condition := Eval(ie.Condition, env)
-	if isError(condition) {
+// shortCircuit The result of && or || when the left operand decides it, so the right is never evaluated. Operands
+// count as true or false as conditions do, with null and false false, and the result is always a boolean
+func shortCircuit(operator string, left object.Object) (result object.Object, done bool) {
+	switch {
+	case operator == "&&" && !isTruthy(left):
+		return FALSE, true
+	case operator == "||" && isTruthy(left):
+		return TRUE, true
+	default:
+		return nil, false
+	}
+}
+
+func evalIfExpression(ie *ast.IfExpression, env *object.Environment, b *Budget) object.Object {
+	condition := eval(ie.Condition, env, b)
+	if isAbrupt(condition) {
 		return condition
 	}
 
 	if isTruthy(condition) {
-		return Eval(ie.Consequence, env)
+		return eval(ie.Consequence, env, b)
 	} else if ie.Alternative != nil {
-		return Eval(ie.Alternative, env)
+		return eval(ie.Alternative, env, b)
 	} else {
 		return NULL
 	}
 }
 
 func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
-	val, ok := env.Get(node.Value)
+	var val object.Object
+
+	switch node.Resolution {
+	case ast.Variable:
+		val = Load(env, node.Depth, node.Slot, node.Value)
+	case ast.Builtin:
+		val = Builtin(node.Value)
+	default:
+		val = unresolved(node)
+	}
+
+	return locate(val, node.Pos(), node.End())
+}
+
+func evalIndexExpression(left, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJECT && index.Type() == object.INTEGER_OBJECT:
+		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.ARRAY_OBJECT:
+		return newError("array index must be %s, got %s", object.INTEGER_OBJECT, index.Type())
+	case left.Type() == object.HASH_OBJECT:
+		return evalHashIndexExpression(left, index)
+	default:
+		return newError("index operator not supported: %s", left.Type())
+	}
+}
+
+// evalArrayIndexExpression Negative indexes count back from the end of the array
+func evalArrayIndexExpression(array, index object.Object) object.Object {
+	elements := array.(*object.Array).Elements
+	idx := index.(*object.Integer).Value
+
+	pos, ok := arrayPosition(idx, len(elements))
+	if !ok {
+		return newError("index out of range: %d (length %d)", idx, len(elements))
+	}
+
+	return elements[pos]
+}
+
+// arrayPosition Resolves a possibly negative index against length, reports false when out of range
+func arrayPosition(idx int64, length int) (int64, bool) {
+	pos := idx
+	if pos < 0 {
+		pos += int64(length)
+	}
+
+	return pos, pos >= 0 && pos < int64(length)
+}
+
+// evalHashIndexExpression Missing keys evaluate to null
+func evalHashIndexExpression(hash, index object.Object) object.Object {
+	key, ok := index.(object.Hashable)
+	if !ok {
+		return newError("unusable as hash key: %s", index.Type())
+	}
+
+	value, ok := hash.(*object.Hash).Get(key)
+	if !ok {
+		return NULL
+	}
+
+	return value
+}
+
+func evalHashLiteral(node *ast.HashLiteral, env *object.Environment, b *Budget) object.Object {
+	hash := object.NewHash()
+
+	for _, pair := range node.Pairs {
+		key := eval(pair.Key, env, b)
+		if isAbrupt(key) {
+			return key
+		}
+
+		hashKey, err := HashKey(key)
+		if err != nil {
+			return locate(err, pair.Key.Pos(), pair.Key.End())
+		}
+
+		value := eval(pair.Value, env, b)
+		if isAbrupt(value) {
+			return value
+		}
+
+		hash.Set(hashKey, value)
+	}
+
+	return hash
+}
+
+// evalInExpression Membership of a key in a hash, an element in an array or a substring in a string
+func evalInExpression(left, right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Hash:
+		key, ok := left.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", left.Type())
+		}
+
+		_, ok = right.Get(key)
+		return nativeBoolToBooleanObject(ok)
+	case *object.Range:
+		switch n := left.(type) {
+		case *object.Integer:
+			return nativeBoolToBooleanObject(right.Contains(n.Value))
+		case *object.Float:
+			return nativeBoolToBooleanObject(n.Value == math.Trunc(n.Value) && right.Contains(int64(n.Value)))
+		default:
+			return FALSE
+		}
+	case *object.Array:
+		for _, el := range right.Elements {
+			if objectsEqual(left, el) {
+				return TRUE
+			}
+		}
+
+		return FALSE
+	case *object.String:
+		str, ok := left.(*object.String)
+		if !ok {
+			return newError("type mismatch: %s in %s", left.Type(), right.Type())
+		}
+
+		return nativeBoolToBooleanObject(strings.Contains(right.Value, str.Value))
+	default:
+		return newError("unknown operator: %s in %s", left.Type(), right.Type())
+	}
+}
+
+// objectsEqual Numbers and hashable objects compare by value, everything else by identity
+func objectsEqual(a, b object.Object) bool {
+	if isNumber(a) && isNumber(b) {
+		return evalInfixExpression("==", a, b) == TRUE
+	}
+
+	ha, ok := a.(object.Hashable)
 	if !ok {
-		return newError("identifier not found: " + node.Value)
+		return a == b
 	}
 
-	return val
+	hb, ok := b.(object.Hashable)
+	if !ok {
+		return false
+	}
+
+	return ha.HashKey() == hb.HashKey()
 }
 
 func evalExpressions(
 	exps []ast.Expression,
 	env *object.Environment,
+	b *Budget,
 ) []object.Object {
 	var result []object.Object
 
 	for _, e := range exps {
-		evaluated := Eval(e, env)
-		if isError(evaluated) {
+		evaluated := eval(e, env, b)
+		if isAbrupt(evaluated) {
 			return []object.Object{evaluated}
 		}
 		result = append(result, evaluated)
@@ -259,27 +609,56 @@ func evalExpressions(
 	return result
 }
 
-// applyFunction Create a new outer environment when evaluating a function
-func applyFunction(fn object.Object, args []object.Object) object.Object {
-	function, ok := fn.(*object.Function)
-	if !ok {
-		return newError("not a function: %s", fn.Type())
-	}
+// applyFunction Create a new outer environment when evaluating a function.
+// Errors raised inside the function body gain a stack frame for the call site
+func applyFunction(
+	fn object.Object,
+	args []object.Object,
+	env *object.Environment,
+	callSite token.Position,
+	b *Budget,
+) object.Object {
+	switch function := fn.(type) {
+	case *object.Function:
+		if len(args) != len(function.Parameters) {
+			return WrongArity(len(args), len(function.Parameters))
+		}
 
-	extendedEnv := extendFunctionEnv(function, args)
-	evaluated := Eval(function.Body, extendedEnv)
+		if err := b.Enter(); err != nil {
+			return err
+		}
+
+		extendedEnv := extendFunctionEnv(function, args)
+		evaluated := unwrapReturnValue(eval(function.Body, extendedEnv, b))
+		b.Leave()
+
+		switch evaluated.(type) {
+		case nil:
+			evaluated = NULL
+		case *object.Break, *object.Continue:
+			evaluated = newError("%s outside of a loop", evaluated.Inspect())
+		}
+
+		if err, ok := evaluated.(*object.Error); ok {
+			err.Trace = append(err.Trace, object.Frame{Function: function.Name, Pos: callSite})
+		}
 
-	return unwrapReturnValue(evaluated)
+		return evaluated
+	case *object.Builtin:
+		return function.Fn(env, args...)
+	default:
+		return NotAFunction(fn)
+	}
 }
 
 func extendFunctionEnv(
 	fn *object.Function,
 	args []object.Object,
 ) *object.Environment {
-	env := object.NewEnclosedEnvironment(fn.Env)
+	env := object.NewEnclosedEnvironment(fn.Env, fn.Slots)
 
 	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
+		env.Store(param.Slot, args[paramIdx])
 	}
 
 	return env
@@ -306,13 +685,39 @@ func isTruthy(obj object.Object) bool {
 	}
 }
 
+// unresolved The error for an identifier the resolver hasn't bound, programs must be resolved before they run
+func unresolved(node *ast.Identifier) *object.Error {
+	return newError("unresolved identifier: %s", node.Value)
+}
+
 func newError(format string, a ...interface{}) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
 
+// locate Attach the span from pos to end to obj if it is an error that hasn't been located yet
+func locate(obj object.Object, pos token.Position, end token.Position) object.Object {
+	if err, ok := obj.(*object.Error); ok && !err.Pos.IsValid() {
+		err.Pos = pos
+		err.End = end
+	}
+
+	return obj
+}
+
 func isError(obj object.Object) bool {
 	if obj != nil {
 		return obj.Type() == object.ERROR_OBJECT
 	}
 	return false
 }
+
+// isAbrupt Reports whether obj is an error, return value, break or continue. These stop the expression that
+// produced them and pass straight up to the function, loop or program that handles them
+func isAbrupt(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Error, *object.ReturnValue, *object.Break, *object.Continue:
+		return true
+	default:
+		return false
+	}
+}
@@ -3,9 +3,12 @@ package evaluator
 import (
 	"testing"
 
+	"github.com/seailly/mi/ast"
+	"github.com/seailly/mi/diagnostic"
 	"github.com/seailly/mi/lexer"
 	"github.com/seailly/mi/object"
 	"github.com/seailly/mi/parser"
+	"github.com/seailly/mi/resolver"
 	"github.com/stretchr/testify/require"
 )
 
@@ -29,6 +32,9 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"3 * 3 * 3 + 10", 37},
 		{"3 * (3 * 3) + 10", 37},
 		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"0xFF", 255},
+		{"0o17 + 0b1010", 25},
+		{"1_000_000 / 0x_3E8", 1000},
 	}
 
 	for _, tt := range tests {
@@ -177,6 +183,26 @@ func TestErrorHandling(t *testing.T) {
 			"-true",
 			"unknown operator: -BOOLEAN",
 		},
+		{
+			"if (false) { 1 } <= 1",
+			"type mismatch: NULL <= INTEGER",
+		},
+		{
+			"true >= false",
+			"unordered operands: BOOLEAN >= BOOLEAN",
+		},
+		{
+			"false < true",
+			"unordered operands: BOOLEAN < BOOLEAN",
+		},
+		{
+			"if (false) { 1 } > if (false) { 1 }",
+			"unordered operands: NULL > NULL",
+		},
+		{
+			"true <= 1",
+			"type mismatch: BOOLEAN <= INTEGER",
+		},
 		{
 			"true + false;",
 			"unknown operator: BOOLEAN + BOOLEAN",
@@ -203,6 +229,10 @@ if (10 > 1) {
 		},
 		{
 			"foobar",
+			"undefined name foobar",
+		},
+		{
+			"if (false) { mut foobar = 1 }; foobar",
 			"identifier not found: foobar",
 		},
 	}
@@ -260,3 +290,409 @@ func TestFunctionApplication(t *testing.T) {
 		testIntegerObject(t, testEval(tt.input), tt.expected)
 	}
 }
+
+func TestEmptyBodies(t *testing.T) {
+	tests := []string{
+		"fn() {}()",
+		"mut f = fn() { mut a = 1; }; f()",
+		"if (true) {}",
+		"if (true) { let a = 1; }",
+		"mut x = fn() {}(); x;",
+	}
+
+	for _, input := range tests {
+		require.Equal(t, NULL, testEval(input), input)
+	}
+
+	// A variable holding null is bound like any other
+	testIntegerObject(t, testEval("mut x = fn() {}(); x = 1; x"), 1)
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedInspect string
+	}{
+		{"5 + true;", "ERROR: main.mi:1:3: type mismatch: INTEGER + BOOLEAN"},
+		{"mut a = 1;\n-true", "ERROR: main.mi:2:1: unknown operator: -BOOLEAN"},
+		{"mut f = fn() {\n  foobar;\n};\nf();", "ERROR: main.mi:2:3: undefined name foobar"},
+		{"mut a = 1;\na(2)", "ERROR: main.mi:2:1: not a function: INTEGER"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewWithFilename("main.mi", tt.input)
+		p := parser.New(l)
+		program := p.ParseProgram()
+		require.Empty(t, p.Errors())
+
+		evaluated := Eval(program, object.NewEnvironment())
+
+		errObj, ok := evaluated.(*object.Error)
+		require.True(t, ok)
+		require.Equal(t, tt.expectedInspect, errObj.Inspect())
+	}
+}
+
+func TestEvalResolves(t *testing.T) {
+	env := object.NewEnvironment()
+
+	program := parser.New(lexer.New("mut a = 1;\na + b")).ParseProgram()
+	errObj, ok := Eval(program, env).(*object.Error)
+	require.True(t, ok)
+	require.False(t, program.Resolved)
+	require.Equal(t, diagnostic.UndefinedName, errObj.Diagnostic().Code)
+	require.Equal(t, "2:5", errObj.Pos.String())
+
+	// A program resolved by the caller is evaluated as it is
+	program = parser.New(lexer.New("mut b = 2; b")).ParseProgram()
+	require.Empty(t, resolver.Resolve(program, env, IsBuiltin))
+	testIntegerObject(t, Eval(program, env), 2)
+
+	// Runtime errors keep their own code
+	errObj, ok = Eval(parser.New(lexer.New("b + true")).ParseProgram(), env).(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, diagnostic.RuntimeError, errObj.Diagnostic().Code)
+}
+
+func TestStringLiteral(t *testing.T) {
+	evaluated := testEval(`"Hello World!"`)
+
+	str, ok := evaluated.(*object.String)
+	require.True(t, ok)
+	require.Equal(t, "Hello World!", str.Value)
+	require.Equal(t, `"Hello World!"`, str.Inspect())
+}
+
+func TestStringConcatenation(t *testing.T) {
+	evaluated := testEval(`mut greet = fn(name) { "Hello" + ", " + name + "!" }; greet("mi")`)
+
+	str, ok := evaluated.(*object.String)
+	require.True(t, ok)
+	require.Equal(t, "Hello, mi!", str.Value)
+}
+
+func TestComparisonOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{"1.5 >= 1", true},
+		{"1 <= 0.5", false},
+		{"9223372036854775808 >= 9223372036854775807", true},
+		{"-9223372036854775809 <= -9223372036854775808", true},
+		{`"abc" <= "abd"`, true},
+		{`"b" >= "abc"`, true},
+		{`"a" <= "a"`, true},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true && true", true},
+		{"true && false", false},
+		{"false && true", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"1 > 2 || 2 >= 3", false},
+		{"false || true && false", false},
+		{"0 && 1", true},
+		{`"" || false`, true},
+		{"if (false) { 1 } || false", false},
+		{"if (false) { 1 } && true", false},
+		{"if (false) { 1 } == if (false) { 1 }", true},
+		{"if (false) { 1 } != false", true},
+		{"!(true && false)", true},
+		// The right operand isn't evaluated once the left decides the result
+		{"false && 1 / 0", false},
+		{"mut f = fn() { true || missing() }; mut missing = 1; f()", true},
+		{"mut n = 0; mut bump = fn() { n += 1; true }; false && bump(); true || bump(); n == 0", true},
+		{"mut n = 0; mut bump = fn() { n += 1; true }; true && bump(); false || bump(); n == 2", true},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestStringComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"a" == "a"`, true},
+		{`"a" == "b"`, false},
+		{`"a" != "b"`, true},
+		{`"a" != "a"`, false},
+		{`"a" < "b"`, true},
+		{`"b" < "a"`, false},
+		{`"abc" > "abb"`, true},
+		{`"a" + "b" == "ab"`, true},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`"Hello" - "World"`, "unknown operator: STRING - STRING"},
+		{`"Hello" + 1`, "type mismatch: STRING + INTEGER"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		require.True(t, ok)
+		require.Equal(t, tt.expectedMessage, errObj.Message)
+	}
+}
+
+func TestArrayLiterals(t *testing.T) {
+	evaluated := testEval("[1, 2 * 2, 3 + 3]")
+
+	result, ok := evaluated.(*object.Array)
+	require.True(t, ok)
+	require.Len(t, result.Elements, 3)
+
+	testIntegerObject(t, result.Elements[0], 1)
+	testIntegerObject(t, result.Elements[1], 4)
+	testIntegerObject(t, result.Elements[2], 6)
+	require.Equal(t, "[1, 4, 6]", result.Inspect())
+}
+
+func TestArrayIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][1]", 2},
+		{"[1, 2, 3][2]", 3},
+		{"mut i = 0; [1][i];", 1},
+		{"[1, 2, 3][1 + 1];", 3},
+		{"mut myArray = [1, 2, 3]; myArray[2];", 3},
+		{"mut myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
+		{"mut myArray = [1, 2, 3]; mut i = myArray[0]; myArray[i]", 2},
+		{"[1, 2, 3][-1]", 3},
+		{"[1, 2, 3][-3]", 1},
+		{"[[1, 2], [3, 4]][1][0]", 3},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), int64(tt.expected.(int)))
+	}
+}
+
+func TestArrayIndexErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"[1, 2, 3][3]", "index out of range: 3 (length 3)"},
+		{"[1, 2, 3][-4]", "index out of range: -4 (length 3)"},
+		{"[][0]", "index out of range: 0 (length 0)"},
+		{`[1, 2, 3]["a"]`, "array index must be INTEGER, got STRING"},
+		{"1[0]", "index operator not supported: INTEGER"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		require.True(t, ok)
+		require.Equal(t, tt.expectedMessage, errObj.Message)
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `mut two = "two";
+{
+  "one": 10 - 9,
+  two: 1 + 1,
+  "thr" + "ee": 6 / 2,
+  4: 4,
+  true: 5,
+  false: 6
+}`
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Hash)
+	require.True(t, ok)
+
+	expected := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{TRUE, 5},
+		{FALSE, 6},
+	}
+
+	require.Equal(t, len(expected), result.Len())
+
+	for i, pair := range result.Pairs() {
+		require.Equal(t, expected[i].key.HashKey(), pair.Key.(object.Hashable).HashKey())
+		testIntegerObject(t, pair.Value, expected[i].value)
+	}
+
+	require.Equal(t, `{"one": 1, "two": 2, "three": 3, 4: 4, true: 5, false: 6}`, result.Inspect())
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`mut key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`{false: 5}[false]`, 5},
+		{`{"a": 1, "a": 2}["a"]`, 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			require.Equal(t, NULL, evaluated)
+		}
+	}
+}
+
+func TestInExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"a" in {"a": 1}`, true},
+		{`"b" in {"a": 1}`, false},
+		{`1 in {1: "a"}`, true},
+		{`"1" in {1: "a"}`, false},
+		{`2 in [1, 2, 3]`, true},
+		{`4 in [1, 2, 3]`, false},
+		{`"b" in ["a", "b"]`, true},
+		{`"ell" in "hello"`, true},
+		{`"xyz" in "hello"`, false},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestHashErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`{"name": "mi"}[fn(x) { x }];`, "unusable as hash key: FUNCTION"},
+		{`{[1]: 2}`, "unusable as hash key: ARRAY"},
+		{`[1] in {}`, "unusable as hash key: ARRAY"},
+		{`1 in 2`, "unknown operator: INTEGER in INTEGER"},
+		{`1 in "abc"`, "type mismatch: INTEGER in STRING"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		require.True(t, ok)
+		require.Equal(t, tt.expectedMessage, errObj.Message)
+	}
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedInspect string
+	}{
+		{"1 / 0", "ERROR: 1:3: division by zero"},
+		{"mut zero = 0;\n10 / (5 - 5 + zero)", "ERROR: 2:4: division by zero"},
+		{"mut add = fn(a, b) { a + b };\nadd(1)", "ERROR: 2:1: wrong number of arguments: got 1, want 2"},
+		{"mut add = fn(a, b) { a + b };\nadd(1, 2, 3)", "ERROR: 2:1: wrong number of arguments: got 3, want 2"},
+		{"fn() { 1 }(1)", "ERROR: 1:1: wrong number of arguments: got 1, want 0"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		require.True(t, ok, tt.input)
+		require.Equal(t, tt.expectedInspect, errObj.Inspect())
+	}
+}
+
+func TestEvalRecoversFromPanics(t *testing.T) {
+	// A hand built AST missing its operand, which the parser would never produce
+	node := &ast.PrefixExpression{Operator: "-"}
+
+	evaluated := Eval(node, object.NewEnvironment())
+
+	errObj, ok := evaluated.(*object.Error)
+	require.True(t, ok)
+	require.Contains(t, errObj.Message, "internal error: ")
+}
+
+func TestErrorStackTrace(t *testing.T) {
+	input := `mut inner = fn(x) {
+  x + true
+};
+mut outer = fn(x) {
+  inner(x)
+};
+mut alias = outer;
+fn() { alias(1) }()`
+
+	l := lexer.NewWithFilename("main.mi", input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+
+	errObj, ok := Eval(program, object.NewEnvironment()).(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "ERROR: main.mi:2:5: type mismatch: INTEGER + BOOLEAN", errObj.Inspect())
+	require.Len(t, errObj.Trace, 3)
+	require.Equal(t, "\tat inner (main.mi:5:3)\n\tat outer (main.mi:8:8)\n\tat <anonymous> (main.mi:8:1)\n", errObj.StackTrace())
+}
+
+func TestErrorStackTraceRecursion(t *testing.T) {
+	input := `mut countdown = fn(n) {
+  if (n == 0) { return 1 / n; }
+  countdown(n - 1)
+};
+countdown(3)`
+
+	errObj, ok := testEval(input).(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "division by zero", errObj.Message)
+	require.Len(t, errObj.Trace, 4)
+
+	for _, frame := range errObj.Trace {
+		require.Equal(t, "countdown", frame.Function)
+	}
+	require.Equal(t, "5:1", errObj.Trace[3].Pos.String())
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	testIntegerObject(t, testEval(`mut café = 1; mut 名前 = café + 1; 名前`), 2)
+	testIntegerObject(t, testEval(`mut x1 = 3; mut x2 = x1 * 2; x2`), 6)
+	testIntegerObject(t, testEval(`len("☕ naïve")`), 7)
+}
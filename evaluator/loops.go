@@ -0,0 +1,93 @@
+package evaluator
+
+import (
+	"github.com/seailly/mi/ast"
+	"github.com/seailly/mi/object"
+)
+
+var (
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
+)
+
+func evalWhileStatement(ws *ast.WhileStatement, env *object.Environment, b *Budget) object.Object {
+	for {
+		condition := eval(ws.Condition, env, b)
+		if isAbrupt(condition) {
+			return condition
+		}
+
+		if !isTruthy(condition) {
+			return NULL
+		}
+
+		if result, done := loopControl(eval(ws.Body, env, b)); done {
+			return result
+		}
+	}
+}
+
+// evalForStatement The loop variable is bound in a fresh scope for every iteration,
+// so closures created in the body each see their own item
+func evalForStatement(fs *ast.ForStatement, env *object.Environment, b *Budget) object.Object {
+	iterable := eval(fs.Iterable, env, b)
+	if isAbrupt(iterable) {
+		return iterable
+	}
+
+	var result object.Object = NULL
+	err := forEach(iterable, func(item object.Object) bool {
+		loopEnv := object.NewEnclosedEnvironment(env, fs.Slots)
+		loopEnv.Store(fs.Variable.Slot, item)
+
+		var done bool
+		result, done = loopControl(eval(fs.Body, loopEnv, b))
+		return !done
+	})
+
+	if err != nil {
+		return locate(err, fs.Iterable.Pos(), fs.Iterable.End())
+	}
+
+	return result
+}
+
+// loopControl Decides what a loop does with the result of its body. done is set when
+// the loop should stop, returning result: null after a break, or the return value
+// or error to keep propagating
+func loopControl(evaluated object.Object) (result object.Object, done bool) {
+	if evaluated == nil {
+		return NULL, false
+	}
+
+	switch evaluated.Type() {
+	case object.BREAK_OBJECT:
+		return NULL, true
+	case object.RETURN_VALUE_OBJECT, object.ERROR_OBJECT:
+		return evaluated, true
+	default:
+		return NULL, false
+	}
+}
+
+// forEach Call fn with every item of iterable until fn returns false, see object.NewIterator
+func forEach(iterable object.Object, fn func(item object.Object) bool) *object.Error {
+	it, err := newIterator(iterable)
+	if err != nil {
+		return err
+	}
+
+	for item, ok := it.Next(); ok && fn(item); item, ok = it.Next() {
+	}
+
+	return nil
+}
+
+func newIterator(iterable object.Object) (*object.Iterator, *object.Error) {
+	it, ok := object.NewIterator(iterable)
+	if !ok {
+		return nil, newError("%s is not iterable", iterable.Type())
+	}
+
+	return it, nil
+}
@@ -0,0 +1,132 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/seailly/mi/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhileStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"mut i = [0]; while (first(i) < 5) { mut i = [first(i) + 1]; } first(i)", 5},
+		{"mut i = 0; while (true) { mut i = i + 1; if (i == 3) { break; } } i", 3},
+		{"mut f = fn() { for (x in range(100)) { if (x == 7) { return x; } } 0 }; f()", 7},
+		{"mut f = fn() { while (true) { return 3; } }; f()", 3},
+		{"mut x = 10; for (x in [1, 2]) { x } x", 10},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestLoopsVisitItems(t *testing.T) {
+	tests := []struct {
+		iterable string
+		expected []string
+	}{
+		{`[1, 2, 3]`, []string{"1", "2", "3"}},
+		{`{"a": 1, "b": 2}`, []string{`"a"`, `"b"`}},
+		{`"héllo"`, []string{`"h"`, `"é"`, `"l"`, `"l"`, `"o"`}},
+		{`range(3)`, []string{"0", "1", "2"}},
+		{`range(2, 5)`, []string{"2", "3", "4"}},
+		{`range(10, 0, -4)`, []string{"10", "6", "2"}},
+		{`range(5, 1)`, []string{}},
+		{`[]`, []string{}},
+	}
+
+	for _, tt := range tests {
+		// Loops are statements, so each iteration reports its item through a builtin
+		visited := []string{}
+		builtins["visit"] = &object.Builtin{Name: "visit", Fn: func(_ *object.Environment, args ...object.Object) object.Object {
+			visited = append(visited, args[0].Inspect())
+			return NULL
+		}}
+
+		evaluated := testEval("for (x in " + tt.iterable + ") { visit(x) }")
+		require.Equal(t, NULL, evaluated, tt.iterable)
+		require.Equal(t, tt.expected, visited, tt.iterable)
+	}
+
+	delete(builtins, "visit")
+}
+
+func TestBreakAndContinue(t *testing.T) {
+	visited := []string{}
+	builtins["visit"] = &object.Builtin{Name: "visit", Fn: func(_ *object.Environment, args ...object.Object) object.Object {
+		visited = append(visited, args[0].Inspect())
+		return NULL
+	}}
+	defer delete(builtins, "visit")
+
+	input := `
+for (x in range(10)) {
+  if (x == 1) { continue; }
+  if (x == 4) { break; }
+  for (y in [x, x]) {
+    if (y == 3) { break; }
+    visit(y);
+  }
+}`
+
+	require.Equal(t, NULL, testEval(input))
+	require.Equal(t, []string{"0", "0", "2", "2"}, visited)
+}
+
+func TestJumpsInsideExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"mut i = 0; while (i < 5) { i += 1; mut x = if (i == 2) { break; } else { i }; visit(x); } visit(i)", []string{"1", "2"}},
+		{"for (i in range(4)) { visit([if (i == 2) { continue; } else { i }]) }", []string{"[0]", "[1]", "[3]"}},
+		{"for (c in [false, true, false]) { visit(1 + if (c) { continue; } else { 2 }) }", []string{"3", "3"}},
+		{"for (x in [1, 2]) { visit({x: if (x == 2) { break; } else { -x }}) }", []string{"{1: -1}"}},
+		{"for (x in [1, 2]) { mut a = [0]; a[0] = if (x == 1) { continue; } else { x }; visit(a) }", []string{"[2]"}},
+		{"mut f = fn(x) { 1 + if (x) { return 10; } else { 2 } }; visit(f(true) + f(false))", []string{"13"}},
+	}
+
+	for _, tt := range tests {
+		visited := []string{}
+		builtins["visit"] = &object.Builtin{Name: "visit", Fn: func(_ *object.Environment, args ...object.Object) object.Object {
+			visited = append(visited, args[0].Inspect())
+			return NULL
+		}}
+
+		evaluated := testEval(tt.input)
+		_, isErr := evaluated.(*object.Error)
+		require.False(t, isErr, "%s: %s", tt.input, evaluated.Inspect())
+		require.Equal(t, tt.expected, visited, tt.input)
+	}
+
+	delete(builtins, "visit")
+}
+
+func TestLoopErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"for (x in 5) { x }", "INTEGER is not iterable"},
+		{"while (missing) { 1 }", "undefined name missing"},
+		{"for (x in [1]) { x + true }", "type mismatch: INTEGER + BOOLEAN"},
+		{"range(1, 2, 0)", "range step must not be zero"},
+		{`range("a")`, "arguments to range must be INTEGER, got STRING"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		require.True(t, ok, tt.input)
+		require.Equal(t, tt.expectedMessage, errObj.Message)
+	}
+}
+
+func TestLoopsDontGrowTheStack(t *testing.T) {
+	input := `mut last = fn() { for (x in range(1000000)) { if (x == 999999) { return x; } } }; last()`
+
+	testIntegerObject(t, testEval(input), 999999)
+}
@@ -0,0 +1,191 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/seailly/mi/object"
+)
+
+// maxPowerBits Largest result of ** on big integers, about 300,000 decimal digits
+const maxPowerBits = 1 << 20
+
+// isNumber Reports whether obj is an integer, a big integer or a float
+func isNumber(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Integer, *object.BigInt, *object.Float:
+		return true
+	default:
+		return false
+	}
+}
+
+// isInteger Reports whether obj is an integer or a big integer
+func isInteger(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Integer, *object.BigInt:
+		return true
+	default:
+		return false
+	}
+}
+
+// floatValue Promotes an integer to a float, obj must be a number
+func floatValue(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.BigInt:
+		f, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return f
+	default:
+		return obj.(*object.Float).Value
+	}
+}
+
+// bigValue Promotes an integer to a big integer, obj must be an integer or a big integer
+func bigValue(obj object.Object) *big.Int {
+	if i, ok := obj.(*object.Integer); ok {
+		return big.NewInt(i.Value)
+	}
+
+	return obj.(*object.BigInt).Value
+}
+
+// evalBigIntInfixExpression Used when an integer operation overflows or either operand is a big integer,
+// results that fit in an int64 are returned as integers
+func evalBigIntInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	leftVal := bigValue(left)
+	rightVal := bigValue(right)
+
+	switch operator {
+	case "+":
+		return object.NewBigInt(new(big.Int).Add(leftVal, rightVal))
+	case "-":
+		return object.NewBigInt(new(big.Int).Sub(leftVal, rightVal))
+	case "*":
+		return object.NewBigInt(new(big.Int).Mul(leftVal, rightVal))
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError("division by zero")
+		}
+		return object.NewBigInt(new(big.Int).Quo(leftVal, rightVal))
+	case "%":
+		if rightVal.Sign() == 0 {
+			return newError("division by zero")
+		}
+		return object.NewBigInt(new(big.Int).Rem(leftVal, rightVal))
+	case "**":
+		if rightVal.Sign() < 0 {
+			return &object.Float{Value: math.Pow(floatValue(left), floatValue(right))}
+		}
+		if leftVal.CmpAbs(big.NewInt(1)) > 0 && (!rightVal.IsInt64() || rightVal.Int64() > maxPowerBits ||
+			int64(leftVal.BitLen()-1)*rightVal.Int64() > maxPowerBits) {
+			return newError("integer too large: %s ** %s", leftVal, rightVal)
+		}
+		return object.NewBigInt(new(big.Int).Exp(leftVal, rightVal, nil))
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) <= 0)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) >= 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// evalFloatInfixExpression Used when either operand is a float, the other is promoted
+func evalFloatInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	leftVal := floatValue(left)
+	rightVal := floatValue(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	case "**":
+		return &object.Float{Value: math.Pow(leftVal, rightVal)}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// integerPower Exponentiation by squaring, a negative exponent gives a float. Reports false on overflow
+func integerPower(base, exponent int64) (object.Object, bool) {
+	if exponent < 0 {
+		return &object.Float{Value: math.Pow(float64(base), float64(exponent))}, true
+	}
+
+	result := int64(1)
+	square := base
+
+	for e := exponent; e > 0; e >>= 1 {
+		var ok bool
+
+		if e&1 == 1 {
+			if result, ok = multiplyInt64(result, square); !ok {
+				return nil, false
+			}
+		}
+
+		if e > 1 {
+			if square, ok = multiplyInt64(square, square); !ok {
+				return nil, false
+			}
+		}
+	}
+
+	return &object.Integer{Value: result}, true
+}
+
+// multiplyInt64 Reports false when a * b overflows
+func multiplyInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+
+	c := a * b
+	if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+
+	return c, true
+}
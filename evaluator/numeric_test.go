@@ -0,0 +1,172 @@
+package evaluator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/seailly/mi/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"1.5", 1.5},
+		{"-2.5", -2.5},
+		{"1e3", 1000},
+		{"1.5 + 1.5", 3},
+		{"1.5 + 1", 2.5},
+		{"1 + 1.5", 2.5},
+		{"7 / 2.0", 3.5},
+		{"10 - 0.5 * 3", 8.5},
+		{"7.5 % 2", 1.5},
+		{"-7.5 % 2", -1.5},
+		{"2 ** 0.5 ** 2", math.Pow(2, 0.25)},
+		{"2 ** -1", 0.5},
+		{"2.0 ** 3", 8},
+		{"mut x = 1; x += 0.5; x", 1.5},
+		{"mut sum = 0; for (x in [1, 2, 4]) { sum += x; } sum / float(3)", 7.0 / 3},
+	}
+
+	for _, tt := range tests {
+		testFloatObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalIntegerArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"7 / 2", 3},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"7 % -3", 1},
+		{"2 ** 10", 1024},
+		{"2 ** 0", 1},
+		{"0 ** 0", 1},
+		{"-2 ** 2", -4},
+		{"(-2) ** 3", -8},
+		{"2 ** 3 ** 2", 512},
+		{"2 ** 62", 1 << 62},
+		{"(-2) ** 63", math.MinInt64},
+		{"1 ** 9223372036854775807", 1},
+		{"2 * 3 ** 2 % 5", 3},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalMixedComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 == 1.0", true},
+		{"1.0 != 1", false},
+		{"1 < 1.5", true},
+		{"2.5 > 2", true},
+		{"0.1 + 0.2 == 0.3", false},
+		{"1.0 in [1, 2]", true},
+		{"1 in [1.0]", true},
+		{"2.0 in range(3)", true},
+		{"2.5 in range(3)", false},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestBigIntPromotion(t *testing.T) {
+	tests := []struct {
+		input        string
+		expected     string
+		expectedType object.ObjectType
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808", object.BIGINT_OBJECT},
+		{"-9223372036854775807 - 2", "-9223372036854775809", object.BIGINT_OBJECT},
+		{"4611686018427387904 * 2", "9223372036854775808", object.BIGINT_OBJECT},
+		{"(-9223372036854775807 - 1) / -1", "9223372036854775808", object.BIGINT_OBJECT},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808", object.BIGINT_OBJECT},
+		{"2 ** 64", "18446744073709551616", object.BIGINT_OBJECT},
+		{"3 ** 40", "12157665459056928801", object.BIGINT_OBJECT},
+		{"123456789012345678901234567890", "123456789012345678901234567890", object.BIGINT_OBJECT},
+		{"0x1_0000_0000_0000_0000", "18446744073709551616", object.BIGINT_OBJECT},
+		{"mut fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(25)", "15511210043330985984000000", object.BIGINT_OBJECT},
+		{"2 ** 64 - 2 ** 64", "0", object.INTEGER_OBJECT},
+		{"2 ** 64 / 2 ** 60", "16", object.INTEGER_OBJECT},
+		{"-(2 ** 63)", "-9223372036854775808", object.INTEGER_OBJECT},
+		{"(2 ** 64 + 5) % 2 ** 64", "5", object.INTEGER_OBJECT},
+		{"-(2 ** 64 + 5) % 2 ** 64", "-5", object.INTEGER_OBJECT},
+		{"(2 ** 64) ** 2", "340282366920938463463374607431768211456", object.BIGINT_OBJECT},
+		{"(2 ** 64) ** -1", "5.421010862427522e-20", object.FLOAT_OBJECT},
+		{"2 ** 64 * 0.5", "9.223372036854776e+18", object.FLOAT_OBJECT},
+		{"mut x = 9223372036854775807; x += 1; x", "9223372036854775808", object.BIGINT_OBJECT},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		require.Equal(t, tt.expectedType, evaluated.Type(), tt.input)
+		require.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestBigIntComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"2 ** 64 > 1", true},
+		{"1 < 2 ** 64", true},
+		{"-(2 ** 64) < 1", true},
+		{"2 ** 64 == 2 ** 64", true},
+		{"2 ** 64 != 2 ** 64 + 1", true},
+		{"2 ** 64 == 18446744073709551616.0", true},
+		{"2 ** 64 + 1 - 1 == 2 ** 64", true},
+		{"2 ** 64 in [1, 2 ** 64]", true},
+		{"2 ** 64 in {18446744073709551616: 1}", true},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestNumericErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"1 % 0", "division by zero"},
+		{"1.5 / 0", "division by zero"},
+		{"1 / 0.0", "division by zero"},
+		{"1.5 % 0", "division by zero"},
+		{"2 ** (2 ** 40)", "integer too large: 2 ** 1099511627776"},
+		{"(2 ** 64) / 0", "division by zero"},
+		{"(2 ** 64) % 0", "division by zero"},
+		{"(2 ** 64) + true", "type mismatch: BIGINT + BOOLEAN"},
+		{`(2 ** 64) < "a"`, "type mismatch: BIGINT < STRING"},
+		{"1.5 + true", "type mismatch: FLOAT + BOOLEAN"},
+		{`"a" * 1.5`, "type mismatch: STRING * FLOAT"},
+		{"-true", "unknown operator: -BOOLEAN"},
+		{"[1, 2][1.0]", "array index must be INTEGER, got FLOAT"},
+		{"{1.5: 1}", "unusable as hash key: FLOAT"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		require.True(t, ok, tt.input)
+		require.Equal(t, tt.expectedMessage, errObj.Message, tt.input)
+	}
+}
+
+func testFloatObject(t *testing.T, obj object.Object, expected float64) {
+	result, ok := obj.(*object.Float)
+	require.Truef(t, ok, "object is not Float. got=%T (%+v)", obj, obj)
+	require.InDelta(t, expected, result.Value, 1e-9)
+}
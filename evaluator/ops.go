@@ -0,0 +1,150 @@
+package evaluator
+
+import (
+	"github.com/seailly/mi/object"
+)
+
+// The functions below are the parts of the language semantics shared with the vm package,
+// so compiled programs behave exactly like evaluated ones
+
+// InfixOperator Apply a binary operator such as + or in
+func InfixOperator(operator string, left, right object.Object) object.Object {
+	return evalInfixExpression(operator, left, right)
+}
+
+// PrefixOperator Apply - or !
+func PrefixOperator(operator string, right object.Object) object.Object {
+	return evalPrefixExpression(operator, right)
+}
+
+// Index Look up left[index]
+func Index(left, index object.Object) object.Object {
+	return evalIndexExpression(left, index)
+}
+
+// SetIndex Store val at left[index], returning val
+func SetIndex(left, index, val object.Object) object.Object {
+	return setIndex(left, index, val)
+}
+
+// IsTruthy Everything except null and false is truthy
+func IsTruthy(obj object.Object) bool {
+	return isTruthy(obj)
+}
+
+// Load The value of the variable name, at slot of the scope depth levels out of env
+func Load(env *object.Environment, depth, slot int, name string) object.Object {
+	if val := env.Load(depth, slot); val != nil {
+		return val
+	}
+
+	// Declared, but the declaration hasn't run
+	return Unbound(name)
+}
+
+// Unbound The error for reading the variable name before its declaration has run
+func Unbound(name string) *object.Error {
+	return newError("identifier not found: " + name)
+}
+
+// Undeclared The error for assigning to the variable name before its declaration has run
+func Undeclared(name string) *object.Error {
+	return assignmentError(object.ErrUndeclared, name)
+}
+
+// NameFunction Give val the name it is bound to when it is a function without one, as declarations and
+// assignments do
+func NameFunction(val object.Object, name string) {
+	nameFunction(val, name)
+}
+
+// Builtin The builtin function called name
+func Builtin(name string) object.Object {
+	if builtin, ok := builtins[name]; ok {
+		return builtin
+	}
+
+	return newError("identifier not found: " + name)
+}
+
+// IsBuiltin Reports whether name is a builtin function, for resolving programs
+func IsBuiltin(name string) bool {
+	_, ok := builtins[name]
+	return ok
+}
+
+// Declare Bind val to the variable name at slot of env, as mut or let statements do
+func Declare(env *object.Environment, slot int, name string, val object.Object, mutable bool) object.Object {
+	nameFunction(val, name)
+
+	if err := env.Declare(slot, val, mutable); err != nil {
+		return newError("cannot redeclare immutable binding: %s", name)
+	}
+
+	return nil
+}
+
+// Assignable The current value of the variable name, which must already be bound
+func Assignable(env *object.Environment, depth, slot int, name string) object.Object {
+	current := env.Load(depth, slot)
+	if current == nil {
+		return Undeclared(name)
+	}
+
+	return current
+}
+
+// Assign Update the variable name, returning val
+func Assign(env *object.Environment, depth, slot int, name string, val object.Object) object.Object {
+	if err := env.Assign(depth, slot, val); err != nil {
+		return assignmentError(err, name)
+	}
+
+	nameFunction(val, name)
+
+	return val
+}
+
+// HashKey val as a hash key, or an error when it can't be used as one
+func HashKey(val object.Object) (object.Hashable, *object.Error) {
+	key, ok := val.(object.Hashable)
+	if !ok {
+		return nil, newError("unusable as hash key: %s", val.Type())
+	}
+
+	return key, nil
+}
+
+// NewIterator An iterator over the items a for loop visits
+func NewIterator(iterable object.Object) (*object.Iterator, *object.Error) {
+	return newIterator(iterable)
+}
+
+// WrongArity The error for calling a function with the wrong number of arguments
+func WrongArity(got, want int) *object.Error {
+	return newError("wrong number of arguments: got %d, want %d", got, want)
+}
+
+// NotAFunction The error for calling something that isn't a function
+func NotAFunction(obj object.Object) *object.Error {
+	return newError("not a function: %s", obj.Type())
+}
+
+// InternalError The error returned when a Go panic is recovered
+func InternalError(r interface{}) *object.Error {
+	return newError("internal error: %v", r)
+}
+
+// nameFunction Functions take the name they are first bound to, for stack traces
+func nameFunction(val object.Object, name string) {
+	switch fn := val.(type) {
+	case *object.Function:
+		if fn.Name == "" {
+			fn.Name = name
+		}
+	case *object.Closure:
+		if fn.Name == "" {
+			fn.Name = name
+		}
+	}
+}
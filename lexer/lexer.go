@@ -1,43 +1,200 @@
 package lexer
 
 import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/seailly/mi/diagnostic"
 	"github.com/seailly/mi/token"
 )
 
 const ASCIINul = 0
 
 type Lexer struct {
-	input        string
-	position     int  // current position in input (points to current char)
-	readPosition int  // current reading position in input (after current char)
-	ch           byte // current char under examination
+	reader       *bufio.Reader
+	filename     string
+	text         []byte // input read since offset start, the literals of tokens are taken from it
+	start        int
+	position     int  // byte offset of the current char in input
+	readPosition int  // byte offset after the current char
+	ch           rune // current char under examination, utf8.RuneError for bytes that aren't valid UTF-8
+	line         int  // line of the current char
+	column       int  // column of the current char, in runes
+	diagnostics  []diagnostic.Diagnostic
+	comments     bool // return comments as COMMENT tokens rather than skipping them
+	failed       bool // a read failed, ending the input
 }
 
 // New
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewWithFilename("", input)
+}
+
+// NewWithFilename Same as New, with filename recorded on every token position
+func NewWithFilename(filename string, input string) *Lexer {
+	return NewReader(filename, strings.NewReader(input))
+}
+
+// NewReader A lexer reading its input from r as tokens are requested, so only the token being read is held in
+// memory. NextToken returns as soon as the char after the token has been read, and never waits for input beyond
+// it. Tokens and their positions are the same as for the input as a string. A failed read ends the input and is
+// reported as a diagnostic
+func NewReader(filename string, r io.Reader) *Lexer {
+	l := &Lexer{reader: bufio.NewReader(r), filename: filename, line: 1}
 	l.readChar()
+
+	// A #! line at the very start lets scripts be executed directly
+	if l.ch == '#' && l.peekChar() == '!' {
+		l.skipLine()
+	}
+
 	return l
 }
 
-// readChar Set the next character and advance position in the input string
+// EmitComments Return comments as COMMENT tokens, with their delimiters, instead of skipping them. The parser
+// collects them in ast.Program.Comments, for tools such as formatters that need to keep them
+func (l *Lexer) EmitComments() {
+	l.comments = true
+}
+
+// readChar Decode the next character and advance position in the input string. Bytes that aren't valid UTF-8
+// are reported and read one at a time as utf8.RuneError
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 0
+	}
+
+	width := 1
+
 	// Check if we have reached the end of the input
-	if l.readPosition >= len(l.input) {
+	if next := l.peek(); len(next) == 0 {
 		l.ch = ASCIINul // ASCII code for "NUL"
 	} else {
-		l.ch = l.input[l.readPosition] // Advance to next character
+		l.ch, width = utf8.DecodeRune(next)
+		l.text = append(l.text, next[:width]...)
+		_, _ = l.reader.Discard(width)
 	}
 
 	l.position = l.readPosition
-	l.readPosition += 1 // Advance next read position
+	l.readPosition += width // Advance next read position
+	l.column += 1
+
+	if l.ch == utf8.RuneError && width == 1 {
+		l.errorAt(diagnostic.InvalidUTF8, l.pos(), "invalid UTF-8 encoding %#x", l.text[len(l.text)-1])
+	}
 }
 
-// NextToken Match ASCII with token.TokenType
+// peek The buffered bytes of the next char, a whole char unless the input ends first. Only the bytes of that char
+// are waited for, so a reader that is still open doesn't stall the lexer. Empty at the end of the input, after
+// reporting the error if it ended because a read failed
+func (l *Lexer) peek() []byte {
+	if l.failed {
+		return nil
+	}
+
+	next, err := l.reader.Peek(1)
+	if len(next) == 1 {
+		if n := charLen(next[0]); n > 1 {
+			next, err = l.reader.Peek(n)
+		}
+	}
+
+	if len(next) == 0 && err != nil && err != io.EOF {
+		l.failed = true
+		l.report(diagnostic.New(diagnostic.ReadFailed, l.posAfter(), l.posAfter(), "reading input: %s", err))
+	}
+
+	return next
+}
+
+// slice The input from offset from up to offset to, which must not be before the last call to mark
+func (l *Lexer) slice(from int, to int) string {
+	from = clamp(from-l.start, 0, len(l.text))
+	to = clamp(to-l.start, from, len(l.text))
+
+	return string(l.text[from:to])
+}
+
+// mark Forget the input before the current char, which no longer needs to be sliced
+func (l *Lexer) mark() {
+	drop := clamp(l.position-l.start, 0, len(l.text))
+	l.text = append(l.text[:0], l.text[drop:]...)
+	l.start += drop
+}
+
+// Diagnostics Return the lexical errors found so far
+func (l *Lexer) Diagnostics() []diagnostic.Diagnostic {
+	return l.diagnostics
+}
+
+// Errors Return the lexical errors found so far formatted as file:line:col: message
+func (l *Lexer) Errors() []string {
+	var errors []string
+	for _, d := range l.diagnostics {
+		errors = append(errors, d.String())
+	}
+
+	return errors
+}
+
+// report Record a lexical error
+func (l *Lexer) report(d diagnostic.Diagnostic) {
+	l.diagnostics = append(l.diagnostics, d)
+}
+
+// errorAt Record an error spanning from pos to the end of the current char
+func (l *Lexer) errorAt(code string, pos token.Position, format string, a ...interface{}) {
+	l.report(diagnostic.New(code, pos, l.posAfter(), format, a...))
+}
+
+// pos Position of the current char
+func (l *Lexer) pos() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Offset:   l.position,
+		Line:     l.line,
+		Column:   l.column,
+	}
+}
+
+// posAfter Position immediately after the current char
+func (l *Lexer) posAfter() token.Position {
+	pos := l.pos()
+	pos.Offset = l.readPosition
+	pos.Column += 1
+	return pos
+}
+
+// locate Set the span of tok, from pos up to the current char
+func (l *Lexer) locate(tok token.Token, pos token.Position) token.Token {
+	tok.Pos = pos
+	tok.End = l.pos()
+	return tok
+}
+
+// NextToken Match characters with token.TokenType
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
+	for l.ch == '/' && (l.peekChar() == '/' || l.peekChar() == '*') {
+		l.mark()
+		pos := l.pos()
+		comment := l.readComment(pos)
+		if l.comments {
+			return l.locate(token.Token{Type: token.COMMENT, Literal: comment}, pos)
+		}
+
+		l.skipWhitespace()
+	}
+
+	l.mark()
+	pos := l.pos()
 
 	switch l.ch {
 	case '=':
@@ -51,6 +208,8 @@ func (l *Lexer) NextToken() token.Token {
 		}
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
+	case ':':
+		tok = newToken(token.COLON, l.ch)
 	case '(':
 		tok = newToken(token.LPAREN, l.ch)
 	case ')':
@@ -58,13 +217,17 @@ func (l *Lexer) NextToken() token.Token {
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		tok = l.readCompound(token.PLUS, token.PLUS_ASSIGN)
 	case '{':
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
 		tok = newToken(token.RBRACE, l.ch)
+	case '[':
+		tok = newToken(token.LBRACKET, l.ch)
+	case ']':
+		tok = newToken(token.RBRACKET, l.ch)
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		tok = l.readCompound(token.MINUS, token.MINUS_ASSIGN)
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -75,51 +238,301 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.BANG, l.ch)
 		}
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		if l.peekChar() == '*' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.POWER, Literal: literal}
+		} else {
+			tok = l.readCompound(token.ASTERISK, token.ASTERISK_ASSIGN)
+		}
+	case '%':
+		tok = newToken(token.PERCENT, l.ch)
 	case '/':
-		tok = newToken(token.SLASH, l.ch)
+		tok = l.readCompound(token.SLASH, token.SLASH_ASSIGN)
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		tok = l.readCompound(token.LT, token.LT_EQ)
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		tok = l.readCompound(token.GT, token.GT_EQ)
+	case '&':
+		tok = l.readDoubled(token.AND)
+	case '|':
+		tok = l.readDoubled(token.OR)
+	case '"':
+		tok.Type = token.STRING
+		tok.Literal = l.readString(pos)
+		if l.ch == ASCIINul {
+			return l.locate(tok, pos)
+		}
 	case ASCIINul:
 		tok.Literal = ""
 		tok.Type = token.EOF
+		return l.locate(tok, pos)
 	default:
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifer()
 			tok.Type = token.LookupIdent(tok.Literal)
-			return tok
+			return l.locate(tok, pos)
 		} else if isDigit(l.ch) {
-			tok.Literal = l.readNumber()
-			tok.Type = token.INT
-			return tok
+			tok.Type, tok.Literal = l.readNumber(pos)
+			return l.locate(tok, pos)
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch)
+			tok = token.Token{Type: token.ILLEGAL, Literal: l.slice(l.position, l.readPosition)}
+			// Invalid UTF-8 has already been reported
+			if !l.invalid() {
+				l.errorAt(diagnostic.IllegalCharacter, pos, "illegal character %q", l.ch)
+			}
 		}
 	}
 
 	l.readChar() // Move read position
-	return tok
+	return l.locate(tok, pos)
+}
+
+// readCompound Read an operator that becomes a compound assignment when followed by =
+func (l *Lexer) readCompound(operator token.TokenType, compound token.TokenType) token.Token {
+	if l.peekChar() != '=' {
+		return newToken(operator, l.ch)
+	}
+
+	ch := l.ch
+	l.readChar()
+	return token.Token{Type: compound, Literal: string(ch) + string(l.ch)}
+}
+
+// readComment Read a // comment up to the end of its line, or a /* */ comment, which may contain other block
+// comments. Leaves the char after the comment current
+func (l *Lexer) readComment(start token.Position) string {
+	position := l.position
+
+	if l.peekChar() == '/' {
+		l.skipLine()
+		return l.slice(position, l.position)
+	}
+
+	l.readChar()
+	l.readChar()
+
+	for depth := 1; depth > 0; {
+		switch {
+		case l.ch == ASCIINul:
+			l.report(diagnostic.New(diagnostic.UnterminatedComment, start, l.pos(), "unterminated block comment").
+				WithHint("add a closing */ to end the comment"))
+			return l.slice(position, l.position)
+		case l.ch == '/' && l.peekChar() == '*':
+			depth++
+			l.readChar()
+		case l.ch == '*' && l.peekChar() == '/':
+			depth--
+			l.readChar()
+		}
+
+		l.readChar()
+	}
+
+	return l.slice(position, l.position)
+}
+
+// readDoubled Read an operator written as the current char twice, such as &&. The char alone is illegal
+func (l *Lexer) readDoubled(operator token.TokenType) token.Token {
+	if l.peekChar() != l.ch {
+		l.errorAt(diagnostic.IllegalCharacter, l.pos(), "illegal character %q, did you mean %s", l.ch, operator)
+		return newToken(token.ILLEGAL, l.ch)
+	}
+
+	ch := l.ch
+	l.readChar()
+	return token.Token{Type: operator, Literal: string(ch) + string(l.ch)}
 }
 
-// readIdentifer Continues reading until keyword is read
+// readIdentifer Continues reading until keyword is read, identifiers are letters followed by letters and digits
 func (l *Lexer) readIdentifer() string {
 	position := l.position
-	for isLetter(l.ch) {
+	for isLetter(l.ch) || unicode.IsDigit(l.ch) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+	return l.slice(position, l.position)
 }
 
-// readNumber
-func (l *Lexer) readNumber() string {
+// readNumber Reads an integer, or a float when followed by a fraction, an exponent or both. Integers may be written
+// in hexadecimal, octal or binary after a 0x, 0o or 0b prefix, and underscores may separate the digits of any number
+func (l *Lexer) readNumber(start token.Position) (token.TokenType, string) {
 	position := l.position
-	for isDigit(l.ch) {
+	var tokenType token.TokenType = token.INT
+
+	if l.ch == '0' {
+		if base, name := prefixBase(l.peekChar()); base != 0 {
+			l.readChar()
+			l.readChar()
+			l.readPrefixed(start, position, base, name)
+
+			return tokenType, l.slice(position, l.position)
+		}
+	}
+
+	separated := l.readDigits(isDigit)
+
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = token.FLOAT
+		l.readChar()
+		separated = l.readDigits(isDigit) && separated
+	}
+
+	if l.ch == 'e' || l.ch == 'E' {
+		tokenType = token.FLOAT
 		l.readChar()
+
+		if l.ch == '+' || l.ch == '-' {
+			l.readChar()
+		}
+
+		if !isDigit(l.ch) {
+			l.report(diagnostic.New(diagnostic.MalformedNumber, start, l.pos(),
+				"malformed number %s, exponent has no digits", l.slice(position, l.position)))
+		}
+
+		separated = l.readDigits(isDigit) && separated
 	}
 
-	return l.input[position:l.position]
+	literal := l.slice(position, l.position)
+
+	switch {
+	case !separated:
+		l.report(diagnostic.New(diagnostic.MalformedNumber, start, l.pos(),
+			"malformed number %s, _ must separate successive digits", literal))
+	case tokenType == token.INT && len(literal) > 1 && literal[0] == '0':
+		// A leading zero makes an octal literal, as in Go
+		if i := strings.IndexAny(literal, "89"); i != -1 {
+			l.report(diagnostic.New(diagnostic.MalformedNumber, start, l.pos(),
+				"malformed number %s, invalid digit %c in octal literal", literal, literal[i]))
+		}
+	}
+
+	return tokenType, literal
+}
+
+// readPrefixed Reads the digits of an integer after its base prefix, reporting a literal without digits, with
+// misplaced underscores or with digits outside the base. Any decimal or hex digits are read so the report covers the
+// whole literal
+func (l *Lexer) readPrefixed(start token.Position, position int, base int, name string) {
+	digits := l.position
+	separated := l.readDigits(isHexDigit)
+	literal := l.slice(position, l.position)
+
+	if strings.Trim(l.slice(digits, l.position), "_") == "" {
+		l.report(diagnostic.New(diagnostic.MalformedNumber, start, l.pos(),
+			"malformed number %s, %s literal has no digits", literal, name))
+		return
+	}
+
+	if !separated {
+		l.report(diagnostic.New(diagnostic.MalformedNumber, start, l.pos(),
+			"malformed number %s, _ must separate successive digits", literal))
+		return
+	}
+
+	for _, ch := range l.slice(digits, l.position) {
+		if ch != '_' && digitValue(ch) >= base {
+			l.report(diagnostic.New(diagnostic.MalformedNumber, start, l.pos(),
+				"malformed number %s, invalid digit %c in %s literal", literal, ch, name))
+			return
+		}
+	}
+}
+
+// readDigits Reads digits and the underscores separating them, returning false when an underscore doesn't sit
+// between two digits. The number or base prefix before the digits counts as a digit, so 0x_FF is well formed
+func (l *Lexer) readDigits(valid func(rune) bool) bool {
+	separated := true
+	previous := '0'
+
+	for valid(l.ch) || l.ch == '_' {
+		if l.ch == '_' && previous == '_' {
+			separated = false
+		}
+
+		previous = l.ch
+		l.readChar()
+	}
+
+	return separated && previous != '_'
+}
+
+// readString Reads a double quoted string, starting at the opening quote and
+// stopping on the closing quote, returning its value with escapes decoded
+func (l *Lexer) readString(start token.Position) string {
+	var out strings.Builder
+
+	for {
+		l.readChar()
+
+		switch l.ch {
+		case '"':
+			return out.String()
+		case ASCIINul:
+			l.report(diagnostic.New(diagnostic.UnterminatedString, start, l.pos(), "unterminated string literal").
+				WithHint(`add a closing " to end the string`))
+			return out.String()
+		case '\\':
+			l.readEscape(&out)
+		default:
+			out.WriteRune(l.ch)
+		}
+	}
+}
+
+// readEscape Decodes the escape sequence whose backslash is the current char
+func (l *Lexer) readEscape(out *strings.Builder) {
+	pos := l.pos()
+	l.readChar()
+
+	switch l.ch {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case 'r':
+		out.WriteByte('\r')
+	case '"':
+		out.WriteByte('"')
+	case '\\':
+		out.WriteByte('\\')
+	case 'u':
+		l.readUnicodeEscape(out, pos)
+	case ASCIINul:
+		// Reported as an unterminated string by readString
+	default:
+		l.errorAt(diagnostic.InvalidEscape, pos, "invalid escape sequence \\%c", l.ch)
+	}
+}
+
+// readUnicodeEscape Decodes the {XXXX} part of a \u{XXXX} escape
+func (l *Lexer) readUnicodeEscape(out *strings.Builder, pos token.Position) {
+	if l.peekChar() != '{' {
+		l.errorAt(diagnostic.InvalidUnicode, pos, "invalid unicode escape, expected \\u{...}")
+		return
+	}
+
+	l.readChar()
+	start := l.readPosition
+	for isHexDigit(l.peekChar()) {
+		l.readChar()
+	}
+	digits := l.slice(start, l.readPosition)
+
+	if l.peekChar() != '}' || len(digits) == 0 || len(digits) > 6 {
+		l.errorAt(diagnostic.InvalidUnicode, pos, "invalid unicode escape, expected \\u{...}")
+		return
+	}
+	l.readChar()
+
+	code, _ := strconv.ParseUint(digits, 16, 32)
+	if !utf8.ValidRune(rune(code)) {
+		l.errorAt(diagnostic.InvalidUnicode, pos, "invalid unicode code point U+%s", strings.ToUpper(digits))
+		return
+	}
+
+	out.WriteRune(rune(code))
 }
 
 // skipWhitespace
@@ -129,26 +542,99 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
+// skipLine Advance to the end of the current line, leaving the newline to be read
+func (l *Lexer) skipLine() {
+	for l.ch != '\n' && l.ch != ASCIINul {
+		l.readChar()
+	}
+}
+
 // peekChar Simliar to readChar except readPosition is not moved forward
-func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
+func (l *Lexer) peekChar() rune {
+	next := l.peek()
+	if len(next) == 0 {
 		return 0
-	} else {
-		return l.input[l.readPosition]
 	}
+
+	ch, _ := utf8.DecodeRune(next)
+	return ch
+}
+
+// charLen The number of bytes in the UTF-8 encoding of the char starting with b, 1 when b can't start a char
+func charLen(b byte) int {
+	switch {
+	case b < 0xC2:
+		return 1
+	case b < 0xE0:
+		return 2
+	case b < 0xF0:
+		return 3
+	case b < 0xF5:
+		return 4
+	default:
+		return 1
+	}
+}
+
+// invalid Whether the current char is a byte that isn't valid UTF-8, rather than an encoded U+FFFD
+func (l *Lexer) invalid() bool {
+	return l.ch == utf8.RuneError && l.readPosition-l.position == 1
 }
 
 // newToken Create a token.Token based on tokenType and character
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
-// isLetter Determines if char is a letter
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// isLetter Determines if char is a letter, in any script
+func isLetter(ch rune) bool {
+	return ch == '_' || unicode.IsLetter(ch)
+}
+
+// prefixBase The base and name of the integer literals introduced by 0 and ch, 0 when ch isn't a base prefix
+func prefixBase(ch rune) (int, string) {
+	switch ch {
+	case 'x', 'X':
+		return 16, "hexadecimal"
+	case 'o', 'O':
+		return 8, "octal"
+	case 'b', 'B':
+		return 2, "binary"
+	}
+
+	return 0, ""
+}
+
+// digitValue The value of a decimal or hex digit
+func digitValue(ch rune) int {
+	switch {
+	case isDigit(ch):
+		return int(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return int(ch-'a') + 10
+	}
+
+	return int(ch-'A') + 10
+}
+
+// isHexDigit
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
 }
 
-// isDigit Currently this only deals with integers
-func isDigit(ch byte) bool {
+// isDigit Number literals are written with ASCII digits
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
+
+func clamp(n int, low int, high int) int {
+	if n < low {
+		return low
+	}
+
+	if n > high {
+		return high
+	}
+
+	return n
+}
@@ -1,14 +1,19 @@
 package lexer
 
 import (
+	"errors"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/seailly/mi/token"
 	"github.com/stretchr/testify/require"
 )
 
 func TestNextToken(t *testing.T) {
-	input := `=+(){},;!-/*55 < 10 > 5`
+	input := `=+(){},;!-/ *55 < 10 > 5[]:in while for break continue let`
 
 	tests := []struct {
 		expectedType    token.TokenType
@@ -31,6 +36,15 @@ func TestNextToken(t *testing.T) {
 		{token.INT, "10"},
 		{token.GT, ">"},
 		{token.INT, "5"},
+		{token.LBRACKET, "["},
+		{token.RBRACKET, "]"},
+		{token.COLON, ":"},
+		{token.IN, "in"},
+		{token.WHILE, "while"},
+		{token.FOR, "for"},
+		{token.BREAK, "break"},
+		{token.CONTINUE, "continue"},
+		{token.LET, "let"},
 		{token.EOF, ""},
 	}
 
@@ -141,3 +155,373 @@ if (5 < 10) {
 		require.Equalf(t, tok.Literal, tt.expectedLiteral, "tests[%d] - literal wrong. expected %s, got %s", i, tt.expectedLiteral, tok.Literal)
 	}
 }
+
+func TestNextToken_CompoundAssign(t *testing.T) {
+	input := `x += 1; x -= 2; x *= 3; x /= 4; x = x+-1`
+
+	expected := []token.TokenType{
+		token.IDENT, token.PLUS_ASSIGN, token.INT, token.SEMICOLON,
+		token.IDENT, token.MINUS_ASSIGN, token.INT, token.SEMICOLON,
+		token.IDENT, token.ASTERISK_ASSIGN, token.INT, token.SEMICOLON,
+		token.IDENT, token.SLASH_ASSIGN, token.INT, token.SEMICOLON,
+		token.IDENT, token.ASSIGN, token.IDENT, token.PLUS, token.MINUS, token.INT,
+		token.EOF,
+	}
+
+	l := New(input)
+
+	for i, tt := range expected {
+		tok := l.NextToken()
+		require.Equalf(t, tt, tok.Type, "tests[%d] - tokentype wrong", i)
+	}
+}
+
+func TestNextToken_Logical(t *testing.T) {
+	input := `a <= b >= c && d || !e < f > g`
+
+	expected := []token.Token{
+		{Type: token.IDENT, Literal: "a"},
+		{Type: token.LT_EQ, Literal: "<="},
+		{Type: token.IDENT, Literal: "b"},
+		{Type: token.GT_EQ, Literal: ">="},
+		{Type: token.IDENT, Literal: "c"},
+		{Type: token.AND, Literal: "&&"},
+		{Type: token.IDENT, Literal: "d"},
+		{Type: token.OR, Literal: "||"},
+		{Type: token.BANG, Literal: "!"},
+		{Type: token.IDENT, Literal: "e"},
+		{Type: token.LT, Literal: "<"},
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.GT, Literal: ">"},
+		{Type: token.IDENT, Literal: "g"},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range expected {
+		tok := l.NextToken()
+		require.Equalf(t, tt.Type, tok.Type, "tests[%d] - tokentype wrong", i)
+		require.Equalf(t, tt.Literal, tok.Literal, "tests[%d] - literal wrong", i)
+	}
+}
+
+func TestNextToken_Comments(t *testing.T) {
+	input := `// leading
+mut x = 10 / 2; // trailing
+/* block /* nested */ still
+comment */ x /= 2 /**/
+// last`
+
+	expected := []token.TokenType{
+		token.MUT, token.IDENT, token.ASSIGN, token.INT, token.SLASH, token.INT, token.SEMICOLON,
+		token.IDENT, token.SLASH_ASSIGN, token.INT,
+		token.EOF,
+	}
+
+	l := New(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		require.Equalf(t, tt, tok.Type, "tests[%d] - tokentype wrong", i)
+	}
+	require.Empty(t, l.Errors())
+
+	comments := []struct {
+		literal string
+		pos     string
+		end     string
+	}{
+		{"// leading", "1:1", "1:11"},
+		{"// trailing", "2:17", "2:28"},
+		{"/* block /* nested */ still\ncomment */", "3:1", "4:11"},
+		{"/**/", "4:19", "4:23"},
+		{"// last", "5:1", "5:8"},
+	}
+
+	l = New(input)
+	l.EmitComments()
+
+	var got []token.Token
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type == token.COMMENT {
+			got = append(got, tok)
+		}
+	}
+
+	require.Len(t, got, len(comments))
+	for i, tt := range comments {
+		require.Equal(t, tt.literal, got[i].Literal, i)
+		require.Equal(t, tt.pos, got[i].Pos.String(), i)
+		require.Equal(t, tt.end, got[i].End.String(), i)
+	}
+}
+
+func TestNextToken_Unicode(t *testing.T) {
+	input := "mut café = \"☕ naïve\";\n名前2 = café"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedPos     string
+		expectedEnd     string
+	}{
+		{token.MUT, "mut", "1:1", "1:4"},
+		{token.IDENT, "café", "1:5", "1:9"},
+		{token.ASSIGN, "=", "1:10", "1:11"},
+		{token.STRING, "☕ naïve", "1:12", "1:21"},
+		{token.SEMICOLON, ";", "1:21", "1:22"},
+		{token.IDENT, "名前2", "2:1", "2:4"},
+		{token.ASSIGN, "=", "2:5", "2:6"},
+		{token.IDENT, "café", "2:7", "2:11"},
+		{token.EOF, "", "2:11", "2:11"},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+		require.Equalf(t, tt.expectedType, tok.Type, "tests[%d] - tokentype wrong", i)
+		require.Equalf(t, tt.expectedLiteral, tok.Literal, "tests[%d] - literal wrong", i)
+		require.Equalf(t, tt.expectedPos, tok.Pos.String(), "tests[%d] - position wrong", i)
+		require.Equalf(t, tt.expectedEnd, tok.End.String(), "tests[%d] - end wrong", i)
+	}
+	require.Empty(t, l.Errors())
+}
+
+func TestNextToken_Numbers(t *testing.T) {
+	input := `5 1.5 0.25 1e3 1E-3 2.5e+10 1.foo 3%2 2**8 0xFF 0XAb_cD 0o17 0O7 0b1010 0B_1 1_000_000 017 1_000.5e1_0`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "5"},
+		{token.FLOAT, "1.5"},
+		{token.FLOAT, "0.25"},
+		{token.FLOAT, "1e3"},
+		{token.FLOAT, "1E-3"},
+		{token.FLOAT, "2.5e+10"},
+		{token.INT, "1"},
+		{token.ILLEGAL, "."},
+		{token.IDENT, "foo"},
+		{token.INT, "3"},
+		{token.PERCENT, "%"},
+		{token.INT, "2"},
+		{token.INT, "2"},
+		{token.POWER, "**"},
+		{token.INT, "8"},
+		{token.INT, "0xFF"},
+		{token.INT, "0XAb_cD"},
+		{token.INT, "0o17"},
+		{token.INT, "0O7"},
+		{token.INT, "0b1010"},
+		{token.INT, "0B_1"},
+		{token.INT, "1_000_000"},
+		{token.INT, "017"},
+		{token.FLOAT, "1_000.5e1_0"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		require.Equalf(t, tt.expectedType, tok.Type, "tests[%d] - tokentype wrong", i)
+		require.Equalf(t, tt.expectedLiteral, tok.Literal, "tests[%d] - literal wrong", i)
+	}
+}
+
+func TestNextToken_Positions(t *testing.T) {
+	input := `mut x = 10;
+  x == 5`
+
+	tests := []struct {
+		expectedType token.TokenType
+		expectedPos  token.Position
+		expectedEnd  token.Position
+	}{
+		{token.MUT, token.Position{Filename: "main.mi", Offset: 0, Line: 1, Column: 1}, token.Position{Filename: "main.mi", Offset: 3, Line: 1, Column: 4}},
+		{token.IDENT, token.Position{Filename: "main.mi", Offset: 4, Line: 1, Column: 5}, token.Position{Filename: "main.mi", Offset: 5, Line: 1, Column: 6}},
+		{token.ASSIGN, token.Position{Filename: "main.mi", Offset: 6, Line: 1, Column: 7}, token.Position{Filename: "main.mi", Offset: 7, Line: 1, Column: 8}},
+		{token.INT, token.Position{Filename: "main.mi", Offset: 8, Line: 1, Column: 9}, token.Position{Filename: "main.mi", Offset: 10, Line: 1, Column: 11}},
+		{token.SEMICOLON, token.Position{Filename: "main.mi", Offset: 10, Line: 1, Column: 11}, token.Position{Filename: "main.mi", Offset: 11, Line: 1, Column: 12}},
+		{token.IDENT, token.Position{Filename: "main.mi", Offset: 14, Line: 2, Column: 3}, token.Position{Filename: "main.mi", Offset: 15, Line: 2, Column: 4}},
+		{token.EQ, token.Position{Filename: "main.mi", Offset: 16, Line: 2, Column: 5}, token.Position{Filename: "main.mi", Offset: 18, Line: 2, Column: 7}},
+		{token.INT, token.Position{Filename: "main.mi", Offset: 19, Line: 2, Column: 8}, token.Position{Filename: "main.mi", Offset: 20, Line: 2, Column: 9}},
+		{token.EOF, token.Position{Filename: "main.mi", Offset: 20, Line: 2, Column: 9}, token.Position{Filename: "main.mi", Offset: 20, Line: 2, Column: 9}},
+	}
+
+	l := NewWithFilename("main.mi", input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		require.Equalf(t, tt.expectedType, tok.Type, "tests[%d] - tokentype wrong", i)
+		require.Equalf(t, tt.expectedPos, tok.Pos, "tests[%d] - pos wrong", i)
+		require.Equalf(t, tt.expectedEnd, tok.End, "tests[%d] - end wrong", i)
+	}
+}
+
+func TestNextToken_Shebang(t *testing.T) {
+	input := "#!/usr/bin/env mi\nmut x = 1;"
+
+	l := New(input)
+
+	tok := l.NextToken()
+	require.Equal(t, token.Token{
+		Type:    token.MUT,
+		Literal: "mut",
+		Pos:     token.Position{Offset: 18, Line: 2, Column: 1},
+		End:     token.Position{Offset: 21, Line: 2, Column: 4},
+	}, tok)
+}
+
+func TestNextToken_Strings(t *testing.T) {
+	input := `"foobar" "foo bar" "a\nb\t\"c\"\\" "\u{48}\u{1F600}" ""`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.STRING, "foobar"},
+		{token.STRING, "foo bar"},
+		{token.STRING, "a\nb\t\"c\"\\"},
+		{token.STRING, "H😀"},
+		{token.STRING, ""},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		require.Equalf(t, tt.expectedType, tok.Type, "tests[%d] - tokentype wrong", i)
+		require.Equalf(t, tt.expectedLiteral, tok.Literal, "tests[%d] - literal wrong", i)
+	}
+
+	require.Empty(t, l.Errors())
+}
+
+func TestNextToken_Errors(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+	}{
+		{`"a\qb"`, []string{"1:3: invalid escape sequence \\q"}},
+		{`"\u{}"`, []string{"1:2: invalid unicode escape, expected \\u{...}"}},
+		{`"\u41"`, []string{"1:2: invalid unicode escape, expected \\u{...}"}},
+		{`"\u{D800}"`, []string{"1:2: invalid unicode code point U+D800"}},
+		{"mut a = \"abc", []string{"1:9: unterminated string literal"}},
+		{"1 @ 2", []string{"1:3: illegal character '@'"}},
+		{"a & b", []string{"1:3: illegal character '&', did you mean &&"}},
+		{"a | b", []string{"1:3: illegal character '|', did you mean ||"}},
+		{"x = 1e+;", []string{"1:5: malformed number 1e+, exponent has no digits"}},
+		{"x = 0x;", []string{"1:5: malformed number 0x, hexadecimal literal has no digits"}},
+		{"x = 0b_;", []string{"1:5: malformed number 0b_, binary literal has no digits"}},
+		{"x = 0b102;", []string{"1:5: malformed number 0b102, invalid digit 2 in binary literal"}},
+		{"x = 0o78;", []string{"1:5: malformed number 0o78, invalid digit 8 in octal literal"}},
+		{"x = 0x1__F;", []string{"1:5: malformed number 0x1__F, _ must separate successive digits"}},
+		{"x = 1__0;", []string{"1:5: malformed number 1__0, _ must separate successive digits"}},
+		{"x = 10_;", []string{"1:5: malformed number 10_, _ must separate successive digits"}},
+		{"x = 1_.5;", []string{"1:5: malformed number 1_.5, _ must separate successive digits"}},
+		{"x = 1.5e_1;", []string{"1:5: malformed number 1.5e, exponent has no digits"}},
+		{"x = 09;", []string{"1:5: malformed number 09, invalid digit 9 in octal literal"}},
+		{"x /* a /* b */", []string{"1:3: unterminated block comment"}},
+		{"é \xff = 1", []string{"1:3: invalid UTF-8 encoding 0xff"}},
+		{"\"a\xe2\x82b\" // \xc3", []string{"1:3: invalid UTF-8 encoding 0xe2", "1:4: invalid UTF-8 encoding 0x82", "1:11: invalid UTF-8 encoding 0xc3"}},
+		{"ü → 1", []string{"1:3: illegal character '→'"}},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+
+		require.Equal(t, tt.expectedErrors, l.Errors(), tt.input)
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	inputs := []string{
+		"mut café = \"☕ naïve\\n\\u{1F600}\";\n名前2 /= café ** 2.5e-3",
+		"#!/usr/bin/env mi\n// comment\nx /* a /* b */ c */ <= 10 && y || !z",
+		"é \xff = \"a\xe2\x82b\" @ 1e+",
+		"\"unterminated",
+		"",
+	}
+
+	for _, input := range inputs {
+		expected := New(input)
+		expected.EmitComments()
+
+		// One byte at a time splits every multi-byte char across reads
+		l := NewReader("", iotest.OneByteReader(strings.NewReader(input)))
+		l.EmitComments()
+
+		for {
+			want, got := expected.NextToken(), l.NextToken()
+			require.Equal(t, want, got, input)
+
+			if want.Type == token.EOF {
+				break
+			}
+		}
+
+		require.Equal(t, expected.Diagnostics(), l.Diagnostics(), input)
+	}
+}
+
+func TestNewReader_Incremental(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+
+	tokens := make(chan token.Token)
+	go func() {
+		l := NewReader("", r)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+			tokens <- tok
+		}
+		close(tokens)
+	}()
+
+	// The pipe stays open, each token must arrive once the char after it is written
+	for _, chunk := range []struct {
+		input    string
+		expected []token.Token
+	}{
+		{"1;\n", []token.Token{{Type: token.INT, Literal: "1"}, {Type: token.SEMICOLON, Literal: ";"}}},
+		{"é\n", []token.Token{{Type: token.IDENT, Literal: "é"}}},
+		{"\"ü\" ", []token.Token{{Type: token.STRING, Literal: "ü"}}},
+	} {
+		go func(input string) { _, _ = w.Write([]byte(input)) }(chunk.input)
+
+		for _, want := range chunk.expected {
+			select {
+			case tok := <-tokens:
+				require.Equal(t, want.Type, tok.Type, chunk.input)
+				require.Equal(t, want.Literal, tok.Literal, chunk.input)
+			case <-time.After(time.Second):
+				t.Fatalf("%s after %q not returned while the input is open", want.Type, chunk.input)
+			}
+		}
+	}
+
+	require.NoError(t, w.Close())
+	_, open := <-tokens
+	require.False(t, open)
+}
+
+func TestNewReader_Error(t *testing.T) {
+	input := io.MultiReader(strings.NewReader("mut a = 1;\nmut"), iotest.ErrReader(errors.New("connection reset")))
+	l := NewReader("remote", input)
+
+	var types []token.TokenType
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		types = append(types, tok.Type)
+	}
+
+	require.Equal(t, []token.TokenType{token.MUT, token.IDENT, token.ASSIGN, token.INT, token.SEMICOLON, token.MUT}, types)
+	require.Equal(t, []string{"remote:2:4: reading input: connection reset"}, l.Errors())
+}
@@ -0,0 +1,140 @@
+// Package mi Embeds the mi language in Go programs.
+//
+//	interp := mi.New()
+//	interp.Set("name", &object.String{Value: "world"})
+//
+//	result, err := interp.Eval(ctx, `"hello " + name`)
+package mi
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/seailly/mi/ast"
+	"github.com/seailly/mi/evaluator"
+	"github.com/seailly/mi/lexer"
+	"github.com/seailly/mi/object"
+	"github.com/seailly/mi/parser"
+	"github.com/seailly/mi/resolver"
+)
+
+var (
+	// ErrForeignProgram Returned when running a program compiled by another interpreter
+	ErrForeignProgram = errors.New("mi: program was compiled by a different interpreter")
+	// ErrStepLimit Wrapped by the *RuntimeError of a run that took more than Limits.MaxSteps steps
+	ErrStepLimit = evaluator.ErrStepLimit
+	// ErrDepthLimit Wrapped by the *RuntimeError of a run that nested calls deeper than Limits.MaxDepth
+	ErrDepthLimit = evaluator.ErrDepthLimit
+)
+
+// Limits Bounds on the steps, call depth and time of each run, see evaluator.Limits
+type Limits = evaluator.Limits
+
+// Interpreter Runs programs against a set of globals that persist between runs, so a variable declared by one
+// program is visible to the next, as lines typed into the REPL are. An Interpreter must not be used concurrently
+type Interpreter struct {
+	// Limits Applied to every run. A run that exceeds them, or whose context is done, fails with a *RuntimeError
+	// wrapping ErrStepLimit, ErrDepthLimit or the error of the context
+	Limits Limits
+	// Output Where puts and print write, os.Stdout when nil
+	Output io.Writer
+
+	env *object.Environment
+}
+
+// Program Source that has been parsed and resolved, ready to be run any number of times by the interpreter that
+// compiled it
+type Program struct {
+	source  string
+	program *ast.Program
+	interp  *Interpreter
+}
+
+// New An interpreter with no globals besides the builtins
+func New() *Interpreter {
+	return &Interpreter{env: object.NewEnvironment()}
+}
+
+// Source The source the program was compiled from
+func (p *Program) Source() string {
+	return p.source
+}
+
+// Compile Parse and resolve source, returning a *ParseError when it has syntax errors or uses undefined names.
+// Globals declared by the program become known to the interpreter, though they stay unbound until it runs
+func (in *Interpreter) Compile(source string) (*Program, error) {
+	p := parser.New(lexer.New(source))
+
+	program := p.ParseProgram()
+	if diagnostics := p.Diagnostics(); len(diagnostics) != 0 {
+		return nil, &ParseError{Source: source, Diagnostics: diagnostics}
+	}
+
+	if diagnostics := resolver.Resolve(program, in.env, evaluator.IsBuiltin); len(diagnostics) != 0 {
+		return nil, &ParseError{Source: source, Diagnostics: diagnostics}
+	}
+
+	return &Program{source: source, program: program, interp: in}, nil
+}
+
+// Run Run a compiled program within the limits of the interpreter, returning the value of its last statement or
+// null when it has none. Errors raised by the program are returned as a *RuntimeError
+func (in *Interpreter) Run(ctx context.Context, program *Program) (object.Object, error) {
+	if program.interp != in {
+		return nil, ErrForeignProgram
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	in.env.SetOutput(in.Output)
+
+	result := evaluator.EvalContext(ctx, program.program, in.env, in.Limits)
+	if errObj, ok := result.(*object.Error); ok {
+		return nil, &RuntimeError{Source: program.source, Err: errObj}
+	}
+
+	if result == nil {
+		return evaluator.NULL, nil
+	}
+
+	return result, nil
+}
+
+// Eval Compile and run source, see Compile and Run
+func (in *Interpreter) Eval(ctx context.Context, source string) (object.Object, error) {
+	program, err := in.Compile(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return in.Run(ctx, program)
+}
+
+// Set Bind the global name to value, replacing any existing binding, even one declared with let
+func (in *Interpreter) Set(name string, value object.Object) {
+	in.env.Set(name, value)
+}
+
+// Register Bind the global name to the Go function fn, so scripts can call it. Arguments are converted to the
+// parameter types of fn, which may be integers, floats, bools, strings, *big.Int, slices, arrays and maps of
+// these, interface{} or object.Object. Arguments that don't convert fail the call with an error naming the
+// argument. fn returns nothing, a value of the same types, an error, or a value and an error; a non-nil error
+// fails the call with its message
+func (in *Interpreter) Register(name string, fn interface{}) error {
+	builtin, err := wrapFunction(name, fn)
+	if err != nil {
+		return err
+	}
+
+	in.env.Set(name, builtin)
+
+	return nil
+}
+
+// Get The value of the global name, false when it isn't bound
+func (in *Interpreter) Get(name string) (object.Object, bool) {
+	return in.env.Get(name)
+}
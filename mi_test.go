@@ -0,0 +1,176 @@
+package mi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seailly/mi/diagnostic"
+	"github.com/seailly/mi/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpreter_Eval(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + 2 * 3", "7"},
+		{`mut greet = fn(name) { "hello " + name }; greet("mi")`, `"hello mi"`},
+		{"mut a = 1", "null"},
+		{"", "null"},
+	}
+
+	for _, tt := range tests {
+		result, err := New().Eval(context.Background(), tt.input)
+		require.NoError(t, err, tt.input)
+		require.Equal(t, tt.expected, result.Inspect(), tt.input)
+	}
+}
+
+func TestInterpreter_GlobalsPersist(t *testing.T) {
+	interp := New()
+	ctx := context.Background()
+
+	_, err := interp.Eval(ctx, "mut count = 1; let limit = 3;")
+	require.NoError(t, err)
+
+	result, err := interp.Eval(ctx, "count += limit; count")
+	require.NoError(t, err)
+	require.Equal(t, "4", result.Inspect())
+
+	_, err = interp.Eval(ctx, "limit = 4")
+	var runtimeErr *RuntimeError
+	require.ErrorAs(t, err, &runtimeErr)
+	require.Equal(t, "mi: 1:1: cannot assign to immutable binding: limit", err.Error())
+}
+
+func TestInterpreter_SetGet(t *testing.T) {
+	interp := New()
+	interp.Set("name", &object.String{Value: "world"})
+
+	_, ok := interp.Get("greeting")
+	require.False(t, ok)
+
+	_, err := interp.Eval(context.Background(), `mut greeting = "hello " + name`)
+	require.NoError(t, err)
+
+	greeting, ok := interp.Get("greeting")
+	require.True(t, ok)
+	require.Equal(t, &object.String{Value: "hello world"}, greeting)
+
+	// Hosts can rebind let declarations
+	_, err = interp.Eval(context.Background(), `let fixed = 1`)
+	require.NoError(t, err)
+	interp.Set("fixed", &object.Integer{Value: 2})
+
+	fixed, _ := interp.Get("fixed")
+	require.Equal(t, &object.Integer{Value: 2}, fixed)
+}
+
+func TestInterpreter_CompileOnce(t *testing.T) {
+	interp := New()
+	ctx := context.Background()
+
+	_, err := interp.Eval(ctx, "mut n = 0")
+	require.NoError(t, err)
+
+	program, err := interp.Compile("n += 1")
+	require.NoError(t, err)
+	require.Equal(t, "n += 1", program.Source())
+
+	for i := 0; i < 3; i++ {
+		_, err := interp.Run(ctx, program)
+		require.NoError(t, err)
+	}
+
+	n, _ := interp.Get("n")
+	require.Equal(t, "3", n.Inspect())
+
+	_, err = New().Run(ctx, program)
+	require.ErrorIs(t, err, ErrForeignProgram)
+}
+
+func TestInterpreter_ParseError(t *testing.T) {
+	_, err := New().Eval(context.Background(), "mut a = ;\nmut b 1;")
+
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	require.Len(t, parseErr.Diagnostics, 2)
+	require.Equal(t, "mi: 1:9: no prefix parse function for ; found (and 1 more errors)", err.Error())
+
+	_, err = New().Eval(context.Background(), "missing + 1")
+	require.ErrorAs(t, err, &parseErr)
+	require.Equal(t, "mi: 1:1: undefined name missing", err.Error())
+}
+
+func TestInterpreter_RuntimeError(t *testing.T) {
+	input := "mut f = fn(x) { x / 0 };\nf(1)"
+
+	_, err := New().Eval(context.Background(), input)
+
+	var runtimeErr *RuntimeError
+	require.ErrorAs(t, err, &runtimeErr)
+	require.Equal(t, "mi: 1:19: division by zero", err.Error())
+	require.Equal(t, input, runtimeErr.Source)
+	require.Equal(t, diagnostic.RuntimeError, runtimeErr.Diagnostic().Code)
+	require.Equal(t, "\tat f (2:1)\n", runtimeErr.StackTrace())
+}
+
+func TestInterpreter_Output(t *testing.T) {
+	var out bytes.Buffer
+
+	interp := New()
+	interp.Output = &out
+
+	_, err := interp.Eval(context.Background(), `puts("a", 1); mut f = fn() { print("b") }; f()`)
+	require.NoError(t, err)
+	require.Equal(t, "a 1\nb", out.String())
+}
+
+func TestInterpreter_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := New().Eval(ctx, "1")
+	require.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestInterpreter_Limits(t *testing.T) {
+	errHost := errors.New("host failure")
+
+	interp := New()
+	require.NoError(t, interp.Register("fail", func() error { return errHost }))
+
+	tests := []struct {
+		input  string
+		limits Limits
+		target error
+	}{
+		{"while (true) { }", Limits{MaxSteps: 10000}, ErrStepLimit},
+		{"while (true) { }", Limits{Timeout: time.Millisecond}, context.DeadlineExceeded},
+		{"fn(f) { f(f) }(fn(f) { f(f) })", Limits{}, ErrDepthLimit},
+		{"mut f = fn(n) { f(n + 1) }; f(0)", Limits{MaxDepth: 10}, ErrDepthLimit},
+		{"fail()", Limits{}, errHost},
+	}
+
+	for _, tt := range tests {
+		interp.Limits = tt.limits
+
+		_, err := interp.Eval(context.Background(), tt.input)
+
+		var runtimeErr *RuntimeError
+		require.ErrorAs(t, err, &runtimeErr, tt.input)
+		require.ErrorIs(t, err, tt.target, tt.input)
+	}
+
+	// Limits apply to each run, not the lifetime of the interpreter
+	interp.Limits = Limits{MaxSteps: 100}
+	for i := 0; i < 3; i++ {
+		result, err := interp.Eval(context.Background(), "1 + 2")
+		require.NoError(t, err)
+		require.Equal(t, "3", result.Inspect())
+	}
+}
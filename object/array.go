@@ -0,0 +1,23 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Array struct {
+	Elements []Object
+}
+
+func (a *Array) Type() ObjectType {
+	return ARRAY_OBJECT
+}
+
+func (a *Array) Inspect() string {
+	var elements []string
+	for _, el := range a.Elements {
+		elements = append(elements, el.Inspect())
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(elements, ", "))
+}
@@ -0,0 +1,25 @@
+package object
+
+import "math/big"
+
+// BigInt An integer outside the int64 range, arithmetic on integers promotes to BigInt on overflow
+type BigInt struct {
+	Value *big.Int
+}
+
+func (b *BigInt) Type() ObjectType {
+	return BIGINT_OBJECT
+}
+
+func (b *BigInt) Inspect() string {
+	return b.Value.String()
+}
+
+// NewBigInt Returns an Integer when v fits in an int64, so each integer value has a single representation
+func NewBigInt(v *big.Int) Object {
+	if v.IsInt64() {
+		return &Integer{Value: v.Int64()}
+	}
+
+	return &BigInt{Value: v}
+}
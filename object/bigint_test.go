@@ -0,0 +1,21 @@
+package object
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBigInt(t *testing.T) {
+	small := NewBigInt(big.NewInt(-42))
+	require.Equal(t, &Integer{Value: -42}, small)
+
+	large, _ := new(big.Int).SetString("-9223372036854775809", 10)
+	obj := NewBigInt(large)
+	require.Equal(t, ObjectType(BIGINT_OBJECT), obj.Type())
+	require.Equal(t, "-9223372036854775809", obj.Inspect())
+
+	other, _ := new(big.Int).SetString("-9223372036854775809", 10)
+	require.Equal(t, obj.(Hashable).HashKey(), NewBigInt(other).(Hashable).HashKey())
+}
@@ -0,0 +1,17 @@
+package object
+
+// BuiltinFunction Native implementation of a builtin, env is the environment it is called from
+type BuiltinFunction func(env *Environment, args ...Object) Object
+
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+}
+
+func (b *Builtin) Type() ObjectType {
+	return BUILTIN_OBJECT
+}
+
+func (b *Builtin) Inspect() string {
+	return "builtin function " + b.Name
+}
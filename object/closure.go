@@ -0,0 +1,69 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/seailly/mi/ast"
+	"github.com/seailly/mi/code"
+	"github.com/seailly/mi/diagnostic"
+)
+
+// CompiledFunction The bytecode of a function literal, kept in the constant pool
+type CompiledFunction struct {
+	Instructions code.Instructions
+	Spans        map[int]diagnostic.Span // source of the instructions that can raise errors, by offset
+	Parameters   []*ast.Identifier
+	Locals       int                 // stack slots each call needs for the variables of the function and its loops
+	Free         []Capture           // where each closure of the function finds the variables it captures
+	Body         *ast.BlockStatement // kept so closures print like evaluated functions
+}
+
+// Capture Where a closure finds a variable when it is created, a cell in a stack slot of the function creating it
+// or one of that function's own free variables
+type Capture struct {
+	Local bool
+	Index int
+}
+
+func (cf *CompiledFunction) Type() ObjectType {
+	return COMPILED_FUNCTION_OBJECT
+}
+
+func (cf *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("CompiledFunction[%p]", cf)
+}
+
+// Closure A compiled function together with the variables of enclosing functions it refers to
+type Closure struct {
+	Name string // set when first bound with mut, let or an assignment, used in stack traces
+	Fn   *CompiledFunction
+	Free []*Cell
+}
+
+// Type Closures are functions as far as scripts can tell
+func (c *Closure) Type() ObjectType {
+	return FUNCTION_OBJECT
+}
+
+func (c *Closure) Inspect() string {
+	var params []string
+	for _, p := range c.Fn.Parameters {
+		params = append(params, p.String())
+	}
+
+	return fmt.Sprintf("fn(%s) {\n%s\n}", strings.Join(params, ", "), c.Fn.Body.String())
+}
+
+// Cell A variable shared by a function and the closures created in it, Value is nil while it is unbound
+type Cell struct {
+	Value Object
+}
+
+func (c *Cell) Type() ObjectType {
+	return CELL_OBJECT
+}
+
+func (c *Cell) Inspect() string {
+	return "cell"
+}
@@ -1,33 +1,170 @@
 package object
 
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+var (
+	// ErrUndeclared Assignment to a variable that hasn't been bound yet
+	ErrUndeclared = errors.New("undeclared identifier")
+	// ErrImmutable Assignment or redeclaration of a let binding
+	ErrImmutable = errors.New("immutable binding")
+)
+
+// Environment The variables of a scope, indexed by the slots the resolver gave them
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store     []Object
+	immutable []bool         // created by the first let binding, most scopes have none
+	names     map[string]int // slots of the outermost environment, which hosts and the REPL bind by name
+	out       io.Writer      // output of the outermost environment, nil for os.Stdout
+	outer     *Environment
 }
 
+// NewEnvironment The outermost environment, holding the globals of a program
 func NewEnvironment() *Environment {
-	s := make(map[string]Object)
-	return &Environment{
-		store: s,
-		outer: nil,
-	}
+	return &Environment{names: make(map[string]int), outer: nil}
 }
 
-func NewEnclosedEnvironment(outer *Environment) *Environment {
-	env := NewEnvironment()
-	env.outer = outer
-	return env
+// NewEnclosedEnvironment A scope of size variables inside outer, for a function call or a loop iteration
+func NewEnclosedEnvironment(outer *Environment, size int) *Environment {
+	return &Environment{store: make([]Object, size), outer: outer}
 }
 
+// Get The value bound to name in the outermost environment
 func (e *Environment) Get(name string) (Object, bool) {
-	object, ok := e.store[name]
-	if !ok && e.outer != nil {
-		object, ok = e.outer.Get(name)
+	g := e.global()
+
+	slot, ok := g.names[name]
+	if !ok || g.store[slot] == nil {
+		return nil, false
 	}
-	return object, ok
+
+	return g.store[slot], true
 }
 
+// Set Bind name mutably in the outermost environment, replacing any existing binding
 func (e *Environment) Set(name string, value Object) Object {
-	e.store[name] = value
+	g := e.global()
+
+	slot := g.Define(name)
+	g.store[slot] = value
+	if slot < len(g.immutable) {
+		g.immutable[slot] = false
+	}
+
 	return value
 }
+
+// SetOutput Direct what the program prints to w, os.Stdout when nil
+func (e *Environment) SetOutput(w io.Writer) {
+	e.global().out = w
+}
+
+// Output Where the program prints, os.Stdout unless SetOutput was called
+func (e *Environment) Output() io.Writer {
+	if out := e.global().out; out != nil {
+		return out
+	}
+
+	return os.Stdout
+}
+
+// Slot The slot of name in the outermost environment, if it has one
+func (e *Environment) Slot(name string) (int, bool) {
+	slot, ok := e.global().names[name]
+	return slot, ok
+}
+
+// Len The number of slots in this environment
+func (e *Environment) Len() int {
+	return len(e.store)
+}
+
+// Define The slot of name in the outermost environment, adding an unbound variable if needed
+func (e *Environment) Define(name string) int {
+	g := e.global()
+
+	if slot, ok := g.names[name]; ok {
+		return slot
+	}
+
+	g.store = append(g.store, nil)
+	g.names[name] = len(g.store) - 1
+
+	return len(g.store) - 1
+}
+
+// Outer The enclosing environment, nil for the outermost one
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
+
+// Load The value of the variable at slot of the environment depth levels out, nil while it is unbound
+func (e *Environment) Load(depth, slot int) Object {
+	return e.at(depth).store[slot]
+}
+
+// Store Bind the variable at slot of this environment mutably, used for parameters and loop variables
+func (e *Environment) Store(slot int, value Object) {
+	e.store[slot] = value
+}
+
+// Declare Bind the variable at slot of this environment, fails with ErrImmutable when it is already bound by let
+func (e *Environment) Declare(slot int, value Object, mutable bool) error {
+	if e.isImmutable(slot) {
+		return ErrImmutable
+	}
+
+	e.store[slot] = value
+	if !mutable {
+		if len(e.immutable) < len(e.store) {
+			e.immutable = append(e.immutable, make([]bool, len(e.store)-len(e.immutable))...)
+		}
+		e.immutable[slot] = true
+	}
+
+	return nil
+}
+
+// Assign Update the variable at slot of the environment depth levels out, fails with ErrUndeclared or ErrImmutable
+func (e *Environment) Assign(depth, slot int, value Object) error {
+	env := e.at(depth)
+
+	if env.store[slot] == nil {
+		return ErrUndeclared
+	}
+
+	if env.isImmutable(slot) {
+		return ErrImmutable
+	}
+
+	env.store[slot] = value
+
+	return nil
+}
+
+// at The environment depth levels out
+func (e *Environment) at(depth int) *Environment {
+	env := e
+	for i := 0; i < depth; i++ {
+		env = env.outer
+	}
+
+	return env
+}
+
+// global The outermost environment
+func (e *Environment) global() *Environment {
+	env := e
+	for env.outer != nil {
+		env = env.outer
+	}
+
+	return env
+}
+
+func (e *Environment) isImmutable(slot int) bool {
+	return slot < len(e.immutable) && e.immutable[slot]
+}
@@ -1,8 +1,11 @@
 package object
 
 import (
-	"github.com/stretchr/testify/require"
+	"bytes"
+	"os"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestEnvironment_Get(t *testing.T) {
@@ -25,11 +28,84 @@ func TestEnvironment_Set(t *testing.T) {
 
 func TestEnvironmnet_NewEnclosedEnvironment_Get(t *testing.T) {
 	outer := NewEnvironment()
-	env := NewEnclosedEnvironment(outer)
+	env := NewEnclosedEnvironment(outer, 0)
 	val := Integer{Value: 1}
-	env.outer.Set("a", &val)
-	obj, ok := env.outer.Get("a")
+	outer.Set("a", &val)
+	obj, ok := env.Get("a")
 
 	require.True(t, ok)
 	require.Equal(t, &val, obj)
-} 
\ No newline at end of file
+}
+
+func TestEnvironment_Load(t *testing.T) {
+	outer := NewEnvironment()
+	slot := outer.Define("a")
+	env := NewEnclosedEnvironment(outer, 2)
+
+	require.Nil(t, env.Load(1, slot))
+
+	outer.Set("a", &Integer{Value: 1})
+	env.Store(1, &Integer{Value: 2})
+
+	require.Equal(t, &Integer{Value: 1}, env.Load(1, slot))
+	require.Equal(t, &Integer{Value: 2}, env.Load(0, 1))
+	require.Nil(t, env.Load(0, 0))
+}
+
+func TestEnvironment_Assign(t *testing.T) {
+	outer := NewEnvironment()
+	env := NewEnclosedEnvironment(outer, 1)
+	outer.Set("a", &Integer{Value: 1})
+
+	require.NoError(t, env.Assign(1, 0, &Integer{Value: 2}))
+
+	val, _ := outer.Get("a")
+	require.Equal(t, &Integer{Value: 2}, val)
+
+	require.ErrorIs(t, env.Assign(0, 0, &Integer{Value: 3}), ErrUndeclared)
+	require.Nil(t, env.Load(0, 0))
+}
+
+func TestEnvironment_Output(t *testing.T) {
+	outer := NewEnvironment()
+	env := NewEnclosedEnvironment(outer, 0)
+	require.Equal(t, os.Stdout, env.Output())
+
+	var out bytes.Buffer
+	env.SetOutput(&out)
+	require.Equal(t, &out, outer.Output())
+
+	outer.SetOutput(nil)
+	require.Equal(t, os.Stdout, env.Output())
+}
+
+func TestEnvironment_Declare(t *testing.T) {
+	outer := NewEnvironment()
+	a := outer.Define("a")
+	env := NewEnclosedEnvironment(outer, 1)
+
+	require.NoError(t, outer.Declare(a, &Integer{Value: 1}, false))
+	require.ErrorIs(t, env.Assign(1, a, &Integer{Value: 2}), ErrImmutable)
+	require.ErrorIs(t, outer.Declare(a, &Integer{Value: 2}, true), ErrImmutable)
+
+	val, _ := env.Get("a")
+	require.Equal(t, &Integer{Value: 1}, val)
+
+	// Shadowing in an inner scope is allowed, and the shadow is mutable
+	require.NoError(t, env.Declare(0, &Integer{Value: 3}, true))
+	require.NoError(t, env.Assign(0, 0, &Integer{Value: 4}))
+	require.Equal(t, &Integer{Value: 1}, env.Load(1, a))
+
+	// Mutable bindings can be redeclared as immutable
+	require.NoError(t, env.Declare(0, &Integer{Value: 5}, false))
+	require.ErrorIs(t, env.Assign(0, 0, &Integer{Value: 6}), ErrImmutable)
+
+	// Globals added after a let binding can still be declared
+	b := outer.Define("b")
+	require.NoError(t, outer.Declare(b, &Integer{Value: 7}, false))
+	require.ErrorIs(t, env.Assign(1, b, &Integer{Value: 8}), ErrImmutable)
+
+	// Set rebinds mutably
+	outer.Set("a", &Integer{Value: 9})
+	require.NoError(t, env.Assign(1, a, &Integer{Value: 10}))
+}
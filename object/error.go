@@ -1,7 +1,38 @@
 package object
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/seailly/mi/diagnostic"
+	"github.com/seailly/mi/token"
+)
+
+// maxTraceFrames Frames beyond this are summarised when printing a stack trace
+const maxTraceFrames = 50
+
 type Error struct {
+	Code    string // diagnostic code, diagnostic.RuntimeError when empty
 	Message string
+	Pos     token.Position // where the error was raised, if known
+	End     token.Position // end of the expression that raised the error
+	Trace   []Frame        // calls the error unwound through, innermost first
+	Cause   error          // the Go error behind it, when raised by the host or an exceeded limit
+}
+
+// Frame A function call on the stack when an error was raised
+type Frame struct {
+	Function string         // name the function was bound to with mut, empty if anonymous
+	Pos      token.Position // call site
+}
+
+func (f Frame) String() string {
+	name := f.Function
+	if name == "" {
+		name = "<anonymous>"
+	}
+
+	return fmt.Sprintf("%s (%s)", name, f.Pos)
 }
 
 func (e *Error) Type() ObjectType {
@@ -9,5 +40,35 @@ func (e *Error) Type() ObjectType {
 }
 
 func (e *Error) Inspect() string {
+	if e.Pos.IsValid() {
+		return "ERROR: " + e.Pos.String() + ": " + e.Message
+	}
+
 	return "ERROR: " + e.Message
 }
+
+// Diagnostic The error as a positioned diagnostic, the stack trace is left out
+func (e *Error) Diagnostic() diagnostic.Diagnostic {
+	code := e.Code
+	if code == "" {
+		code = diagnostic.RuntimeError
+	}
+
+	return diagnostic.New(code, e.Pos, e.End, "%s", e.Message)
+}
+
+// StackTrace One "\tat name (file:line:col)" line per frame, innermost first
+func (e *Error) StackTrace() string {
+	var out strings.Builder
+
+	for i, frame := range e.Trace {
+		if i == maxTraceFrames {
+			out.WriteString(fmt.Sprintf("\t... %d more\n", len(e.Trace)-i))
+			break
+		}
+
+		out.WriteString("\tat " + frame.String() + "\n")
+	}
+
+	return out.String()
+}
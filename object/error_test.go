@@ -0,0 +1,48 @@
+package object
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seailly/mi/diagnostic"
+	"github.com/seailly/mi/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestError_StackTrace(t *testing.T) {
+	err := &Error{
+		Message: "boom",
+		Trace: []Frame{
+			{Function: "add", Pos: token.Position{Filename: "main.mi", Line: 3, Column: 5}},
+			{Pos: token.Position{Line: 9, Column: 1}},
+		},
+	}
+
+	require.Equal(t, "\tat add (main.mi:3:5)\n\tat <anonymous> (9:1)\n", err.StackTrace())
+}
+
+func TestError_StackTraceTruncated(t *testing.T) {
+	err := &Error{Message: "boom"}
+	for i := 0; i < maxTraceFrames+10; i++ {
+		err.Trace = append(err.Trace, Frame{Function: "f", Pos: token.Position{Line: 1, Column: 1}})
+	}
+
+	lines := strings.Split(strings.TrimSuffix(err.StackTrace(), "\n"), "\n")
+	require.Len(t, lines, maxTraceFrames+1)
+	require.Equal(t, "\t... 10 more", lines[maxTraceFrames])
+}
+
+func TestError_Diagnostic(t *testing.T) {
+	err := &Error{
+		Message: "division by zero",
+		Pos:     token.Position{Filename: "main.mi", Offset: 2, Line: 1, Column: 3},
+		End:     token.Position{Filename: "main.mi", Offset: 3, Line: 1, Column: 4},
+		Trace:   []Frame{{Function: "f", Pos: token.Position{Line: 2, Column: 1}}},
+	}
+
+	d := err.Diagnostic()
+	require.Equal(t, diagnostic.Error, d.Severity)
+	require.Equal(t, diagnostic.RuntimeError, d.Code)
+	require.Equal(t, "main.mi:1:3: division by zero", d.String())
+	require.Equal(t, err.End, d.Span.End)
+}
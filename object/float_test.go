@@ -0,0 +1,27 @@
+package object
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFloat_Inspect(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{1.5, "1.5"},
+		{1, "1.0"},
+		{-3, "-3.0"},
+		{0.001, "0.001"},
+		{1e21, "1e+21"},
+		{math.Inf(1), "+Inf"},
+		{math.NaN(), "NaN"},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, (&Float{Value: tt.value}).Inspect())
+	}
+}
@@ -7,9 +7,11 @@ import (
 )
 
 type Function struct {
+	Name       string // set when first bound with mut, used in stack traces
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
+	Slots      int // variables each call needs
 }
 
 func (f *Function) Type() ObjectType {
@@ -0,0 +1,101 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HashKey Identifies a hashable value, two keys are equal only when their values are equal
+type HashKey struct {
+	Type  ObjectType
+	Value uint64 // integers and booleans
+	Text  string // strings and big integers
+}
+
+// Hashable Objects that can be used as hash keys
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+func (b *Boolean) HashKey() HashKey {
+	var value uint64
+	if b.Value {
+		value = 1
+	}
+
+	return HashKey{Type: b.Type(), Value: value}
+}
+
+func (s *String) HashKey() HashKey {
+	return HashKey{Type: s.Type(), Text: s.Value}
+}
+
+func (b *BigInt) HashKey() HashKey {
+	return HashKey{Type: b.Type(), Text: b.Value.String()}
+}
+
+// HashPair Keeps the original key object alongside its value
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash Pairs are kept in insertion order
+type Hash struct {
+	index map[HashKey]int
+	pairs []HashPair
+}
+
+func NewHash() *Hash {
+	return &Hash{index: make(map[HashKey]int)}
+}
+
+func (h *Hash) Type() ObjectType {
+	return HASH_OBJECT
+}
+
+func (h *Hash) Inspect() string {
+	var pairs []string
+	for _, pair := range h.pairs {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(pairs, ", "))
+}
+
+// Get Look up the value stored under key
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	i, ok := h.index[key.HashKey()]
+	if !ok {
+		return nil, false
+	}
+
+	return h.pairs[i].Value, true
+}
+
+// Set Store value under key, replacing an existing value in place
+func (h *Hash) Set(key Hashable, value Object) {
+	hashKey := key.HashKey()
+	if i, ok := h.index[hashKey]; ok {
+		h.pairs[i].Value = value
+		return
+	}
+
+	h.index[hashKey] = len(h.pairs)
+	h.pairs = append(h.pairs, HashPair{Key: key, Value: value})
+}
+
+// Len Number of pairs in the hash
+func (h *Hash) Len() int {
+	return len(h.pairs)
+}
+
+// Pairs Return the pairs in insertion order
+func (h *Hash) Pairs() []HashPair {
+	return h.pairs
+}
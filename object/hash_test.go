@@ -0,0 +1,37 @@
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashKey(t *testing.T) {
+	hello1 := &String{Value: "Hello World"}
+	hello2 := &String{Value: "Hello World"}
+	diff := &String{Value: "My name is johnny"}
+
+	require.Equal(t, hello1.HashKey(), hello2.HashKey())
+	require.NotEqual(t, hello1.HashKey(), diff.HashKey())
+
+	require.Equal(t, (&Integer{Value: 1}).HashKey(), (&Integer{Value: 1}).HashKey())
+	require.NotEqual(t, (&Integer{Value: 1}).HashKey(), (&Boolean{Value: true}).HashKey())
+	require.NotEqual(t, (&Integer{Value: 1}).HashKey(), (&String{Value: "1"}).HashKey())
+}
+
+func TestHash_SetGet(t *testing.T) {
+	hash := NewHash()
+	hash.Set(&String{Value: "b"}, &Integer{Value: 1})
+	hash.Set(&Integer{Value: 1}, &Integer{Value: 2})
+	hash.Set(&String{Value: "b"}, &Integer{Value: 3})
+
+	value, ok := hash.Get(&String{Value: "b"})
+	require.True(t, ok)
+	require.Equal(t, &Integer{Value: 3}, value)
+
+	_, ok = hash.Get(&Boolean{Value: true})
+	require.False(t, ok)
+
+	require.Equal(t, 2, hash.Len())
+	require.Equal(t, `{"b": 3, 1: 2}`, hash.Inspect())
+}
@@ -0,0 +1,70 @@
+package object
+
+import "unicode/utf8"
+
+// Iterator Walks the items of an iterable value, used to run for loops
+type Iterator struct {
+	next func() (Object, bool)
+}
+
+func (it *Iterator) Type() ObjectType {
+	return ITERATOR_OBJECT
+}
+
+func (it *Iterator) Inspect() string {
+	return "iterator"
+}
+
+// Next Returns the next item, or false once the iterable is exhausted
+func (it *Iterator) Next() (Object, bool) {
+	return it.next()
+}
+
+// NewIterator Arrays yield their elements, hashes their keys, strings their characters and ranges their
+// integers. Reports false for anything else. The items are fixed when the iterator is created, except that
+// array elements assigned in place are seen
+func NewIterator(iterable Object) (*Iterator, bool) {
+	i := 0
+
+	switch iterable := iterable.(type) {
+	case *Array:
+		elements := iterable.Elements
+		return &Iterator{next: func() (Object, bool) {
+			if i >= len(elements) {
+				return nil, false
+			}
+			i++
+			return elements[i-1], true
+		}}, true
+	case *Hash:
+		pairs := iterable.Pairs()
+		return &Iterator{next: func() (Object, bool) {
+			if i >= len(pairs) {
+				return nil, false
+			}
+			i++
+			return pairs[i-1].Key, true
+		}}, true
+	case *String:
+		value := iterable.Value
+		return &Iterator{next: func() (Object, bool) {
+			if i >= len(value) {
+				return nil, false
+			}
+			r, size := utf8.DecodeRuneInString(value[i:])
+			i += size
+			return &String{Value: string(r)}, true
+		}}, true
+	case *Range:
+		n := iterable.Len()
+		return &Iterator{next: func() (Object, bool) {
+			if int64(i) >= n {
+				return nil, false
+			}
+			i++
+			return &Integer{Value: iterable.Start + int64(i-1)*iterable.Step}, true
+		}}, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,23 @@
+package object
+
+// Break Signals a break statement up to the enclosing loop
+type Break struct{}
+
+func (b *Break) Type() ObjectType {
+	return BREAK_OBJECT
+}
+
+func (b *Break) Inspect() string {
+	return "break"
+}
+
+// Continue Signals a continue statement up to the enclosing loop
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType {
+	return CONTINUE_OBJECT
+}
+
+func (c *Continue) Inspect() string {
+	return "continue"
+}
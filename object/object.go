@@ -4,11 +4,24 @@ type ObjectType string
 
 const (
 	INTEGER_OBJECT      = "INTEGER"
+	FLOAT_OBJECT        = "FLOAT"
+	BIGINT_OBJECT       = "BIGINT"
 	BOOLEAN_OBJECT      = "BOOLEAN"
+	STRING_OBJECT       = "STRING"
 	NULL_OBJECT         = "NULL"
 	RETURN_VALUE_OBJECT = "RETURN_VALUE"
 	ERROR_OBJECT        = "ERROR"
 	FUNCTION_OBJECT     = "FUNCTION"
+	ARRAY_OBJECT        = "ARRAY"
+	HASH_OBJECT         = "HASH"
+	BUILTIN_OBJECT      = "BUILTIN"
+	RANGE_OBJECT        = "RANGE"
+	BREAK_OBJECT        = "BREAK"
+	CONTINUE_OBJECT     = "CONTINUE"
+	ITERATOR_OBJECT     = "ITERATOR"
+
+	COMPILED_FUNCTION_OBJECT = "COMPILED_FUNCTION"
+	CELL_OBJECT              = "CELL"
 )
 
 // Object Each value represents itself
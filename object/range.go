@@ -0,0 +1,44 @@
+package object
+
+import "fmt"
+
+// Range A lazy sequence of integers from Start up to, but not including, Stop
+type Range struct {
+	Start int64
+	Stop  int64
+	Step  int64 // never 0
+}
+
+func (r *Range) Type() ObjectType {
+	return RANGE_OBJECT
+}
+
+func (r *Range) Inspect() string {
+	return fmt.Sprintf("range(%d, %d, %d)", r.Start, r.Stop, r.Step)
+}
+
+// Len Number of integers in the range
+func (r *Range) Len() int64 {
+	if r.Step > 0 && r.Start < r.Stop {
+		return (r.Stop - r.Start + r.Step - 1) / r.Step
+	}
+
+	if r.Step < 0 && r.Start > r.Stop {
+		return (r.Start - r.Stop - r.Step - 1) / -r.Step
+	}
+
+	return 0
+}
+
+// Contains Reports whether n is one of the integers in the range
+func (r *Range) Contains(n int64) bool {
+	if r.Step > 0 && (n < r.Start || n >= r.Stop) {
+		return false
+	}
+
+	if r.Step < 0 && (n > r.Start || n <= r.Stop) {
+		return false
+	}
+
+	return (n-r.Start)%r.Step == 0
+}
@@ -0,0 +1,33 @@
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		r        *Range
+		len      int64
+		contains []int64
+		excludes []int64
+	}{
+		{&Range{Start: 0, Stop: 5, Step: 1}, 5, []int64{0, 4}, []int64{-1, 5}},
+		{&Range{Start: 1, Stop: 10, Step: 3}, 3, []int64{1, 4, 7}, []int64{2, 10}},
+		{&Range{Start: 10, Stop: 0, Step: -4}, 3, []int64{10, 6, 2}, []int64{0, 8, 14}},
+		{&Range{Start: 5, Stop: 1, Step: 1}, 0, []int64{}, []int64{1, 5}},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.len, tt.r.Len(), tt.r.Inspect())
+
+		for _, v := range tt.contains {
+			require.True(t, tt.r.Contains(v), "%s contains %d", tt.r.Inspect(), v)
+		}
+
+		for _, v := range tt.excludes {
+			require.False(t, tt.r.Contains(v), "%s excludes %d", tt.r.Inspect(), v)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type String struct {
+	Value string
+}
+
+func (s *String) Type() ObjectType {
+	return STRING_OBJECT
+}
+
+// Inspect Quotes the string with mi's own escapes, so it reads back as the same string literal. Characters that
+// aren't printable are written as \u{...}
+func (s *String) Inspect() string {
+	var out strings.Builder
+
+	out.WriteByte('"')
+	for _, r := range s.Value {
+		switch r {
+		case '\n':
+			out.WriteString(`\n`)
+		case '\t':
+			out.WriteString(`\t`)
+		case '\r':
+			out.WriteString(`\r`)
+		case '"':
+			out.WriteString(`\"`)
+		case '\\':
+			out.WriteString(`\\`)
+		default:
+			if unicode.IsPrint(r) {
+				out.WriteRune(r)
+			} else {
+				fmt.Fprintf(&out, `\u{%x}`, r)
+			}
+		}
+	}
+	out.WriteByte('"')
+
+	return out.String()
+}
@@ -0,0 +1,37 @@
+package object
+
+import (
+	"testing"
+
+	"github.com/seailly/mi/lexer"
+	"github.com/seailly/mi/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestString_Inspect(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"hello", `"hello"`},
+		{"café 日本", `"café 日本"`},
+		{"a\nb\tc\rd", `"a\nb\tc\rd"`},
+		{`say "hi" \o/`, `"say \"hi\" \\o/"`},
+		{"\a", `"\u{7}"`},
+		{"\x00", `"\u{0}"`},
+		{"\u200b", `"\u{200b}"`},
+		{"\U0001F600", `"😀"`},
+	}
+
+	for _, tt := range tests {
+		inspected := (&String{Value: tt.value}).Inspect()
+		require.Equal(t, tt.expected, inspected)
+
+		// The result is a mi string literal for the same value
+		l := lexer.New(inspected)
+		tok := l.NextToken()
+		require.Empty(t, l.Errors(), inspected)
+		require.Equal(t, token.TokenType(token.STRING), tok.Type)
+		require.Equal(t, tt.value, tok.Literal)
+	}
+}
@@ -1,10 +1,12 @@
 package parser
 
 import (
-	"fmt"
+	"errors"
+	"math/big"
 	"strconv"
 
 	"github.com/seailly/mi/ast"
+	"github.com/seailly/mi/diagnostic"
 	"github.com/seailly/mi/token"
 )
 
@@ -14,26 +16,59 @@ func (p *Parser) ParseProgram() *ast.Program {
 	program.Statements = []ast.Statement{}
 
 	for p.curToken.Type != token.EOF {
+		level := p.braces
 		stmt := p.parseStatement()
+
+		// Statements with syntax errors are left out, so the partial AST stays well formed
+		if p.panicking {
+			p.synchronize(level)
+			continue
+		}
+
 		if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
+
 		p.nextToken()
 	}
 
+	program.Comments = p.comments
+
 	return program
 }
 
-// parseStatement
+// parseStatement Returns nil when the statement failed to parse
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.MUT:
-		return p.parseMutStatement()
+		if stmt := p.parseMutStatement(); stmt != nil {
+			return stmt
+		}
+	case token.LET:
+		if stmt := p.parseLetStatement(); stmt != nil {
+			return stmt
+		}
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.WHILE:
+		if stmt := p.parseWhileStatement(); stmt != nil {
+			return stmt
+		}
+	case token.FOR:
+		if stmt := p.parseForStatement(); stmt != nil {
+			return stmt
+		}
+	case token.BREAK:
+		return p.parseBreakStatement()
+	case token.CONTINUE:
+		return p.parseContinueStatement()
 	default:
-		return p.parseExpressionStatement()
+		if stmt := p.parseExpressionStatement(); stmt.Expression != nil {
+			return stmt
+		}
 	}
+
+	return nil
 }
 
 // parseBlockStatement
@@ -41,16 +76,37 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
 
+	p.depth++
+	defer func() { p.depth-- }()
+
 	p.nextToken()
 
 	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
+		level := p.braces
 		stmt := p.parseStatement()
+
+		// Statements with syntax errors are left out, so the partial AST stays well formed
+		if p.panicking {
+			p.synchronize(level)
+			continue
+		}
+
 		if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
+
 		p.nextToken()
 	}
 
+	if p.curTokenIs(token.EOF) {
+		d := diagnostic.New(diagnostic.UnclosedBlock, p.curToken.Pos, p.curToken.End,
+			"expected } to close block opened at %s, got EOF instead", block.Token.Pos)
+		p.report(d.WithLabel(block.Token.Pos, block.Token.End, "block opened here").
+			WithHint("add a } to close the block"))
+	}
+
+	block.RBrace = p.curToken
+
 	return block
 }
 
@@ -64,14 +120,19 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 		return identifiers
 	}
 
-	p.nextToken()
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
 
 	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	identifiers = append(identifiers, ident)
 
 	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
-		p.nextToken() // Why do we need this?
+		p.nextToken() // Skip the comma
+
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
 
 		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 		identifiers = append(identifiers, ident)
@@ -93,25 +154,53 @@ func (p *Parser) parseIdentifier() ast.Expression {
 func (p *Parser) parseMutStatement() *ast.MutStatement {
 	stmt := &ast.MutStatement{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
+	name, value, ok := p.parseBinding()
+	if !ok {
 		return nil
 	}
 
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	stmt.Name = name
+	stmt.Value = value
 
-	if !p.expectPeek(token.ASSIGN) {
+	return stmt
+}
+
+// parseLetStatement
+func (p *Parser) parseLetStatement() *ast.LetStatement {
+	stmt := &ast.LetStatement{Token: p.curToken}
+
+	name, value, ok := p.parseBinding()
+	if !ok {
 		return nil
 	}
 
+	stmt.Name = name
+	stmt.Value = value
+
+	return stmt
+}
+
+// parseBinding Parses the `name = value` shared by mut and let statements
+func (p *Parser) parseBinding() (*ast.Identifier, ast.Expression, bool) {
+	if !p.expectPeek(token.IDENT) {
+		return nil, nil, false
+	}
+
+	name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.ASSIGN) {
+		return nil, nil, false
+	}
+
 	p.nextToken()
 
-	stmt.Value = p.parseExpression(LOWEST)
+	value := p.parseExpression(LOWEST)
 
-	for !p.curTokenIs(token.SEMICOLON) {
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
-	return stmt
+	return name, value, true
 }
 
 // parseReturnStatement
@@ -122,7 +211,113 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 
 	stmt.ReturnValue = p.parseExpression(LOWEST)
 
-	for !p.curTokenIs(token.SEMICOLON) {
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseWhileStatement
+func (p *Parser) parseWhileStatement() *ast.WhileStatement {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseForStatement for (x in iterable) { ... }
+func (p *Parser) parseForStatement() *ast.ForStatement {
+	stmt := &ast.ForStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Variable = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseLoopBody Parse a block in which break and continue are allowed
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	p.loops++
+	defer func() { p.loops-- }()
+
+	return p.parseBlockStatement()
+}
+
+// parseBreakStatement
+func (p *Parser) parseBreakStatement() ast.Statement {
+	stmt := &ast.BreakStatement{Token: p.curToken}
+
+	if p.loops == 0 {
+		p.errorAt(diagnostic.OutsideLoop, p.curToken, "break outside of a loop")
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseContinueStatement
+func (p *Parser) parseContinueStatement() ast.Statement {
+	stmt := &ast.ContinueStatement{Token: p.curToken}
+
+	if p.loops == 0 {
+		p.errorAt(diagnostic.OutsideLoop, p.curToken, "continue outside of a loop")
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -187,16 +382,47 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	}
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
-	if err != nil {
-		p.errors = append(p.errors, fmt.Sprintf("could not parse %q as integer", p.curToken.Literal))
+	if errors.Is(err, strconv.ErrRange) {
+		if n, ok := new(big.Int).SetString(p.curToken.Literal, 0); ok {
+			lit.Big = n
+			return lit
+		}
+	}
+
+	if err != nil && !errors.Is(err, strconv.ErrSyntax) {
+		p.errorAt(diagnostic.InvalidInteger, p.curToken, "could not parse %q as integer", p.curToken.Literal)
+		return nil
+	}
+
+	// Malformed literals were already reported by the lexer, so the literal is kept to avoid a second error
+	lit.Value = value
+
+	return lit
+}
+
+// parseFloatLiteral
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{
+		Token: p.curToken,
+	}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		p.errorAt(diagnostic.InvalidFloat, p.curToken, "could not parse %q as float", p.curToken.Literal)
 		return nil
 	}
 
+	// Malformed literals were already reported by the lexer, so the literal is kept to avoid a second error
 	lit.Value = value
 
 	return lit
 }
 
+// parseStringLiteral
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+}
+
 // parseBoolean
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
@@ -226,11 +452,40 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	precedence := p.curPrecedence()
 	p.nextToken()
 
+	// ** is right associative, so 2 ** 3 ** 2 is 2 ** (3 ** 2)
+	if expression.Operator == token.POWER {
+		precedence--
+	}
+
 	expression.Right = p.parseExpression(precedence)
 
 	return expression
 }
 
+// parseAssignExpression Assignment is right associative, so a = b = 1 assigns both
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Target:   target,
+	}
+
+	switch target.(type) {
+	case *ast.Identifier, *ast.IndexExpression:
+	default:
+		p.report(diagnostic.New(diagnostic.InvalidAssignment, target.Pos(), target.End(),
+			"cannot assign to %s", target.String()).
+			WithHint("only names and index expressions can be assigned to"))
+		return nil
+	}
+
+	p.nextToken()
+
+	expression.Value = p.parseExpression(LOWEST)
+
+	return expression
+}
+
 // parseIfExpression
 func (p *Parser) parseIfExpression() ast.Expression {
 	expression := &ast.IfExpression{Token: p.curToken}
@@ -268,35 +523,93 @@ func (p *Parser) parseIfExpression() ast.Expression {
 // parseCallExpression
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
-	exp.Arguments = p.parseCallArguments()
+	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	exp.RParen = p.curToken
 
 	return exp
 }
 
-// parseCallArguments Parses the parameter arguments
-func (p *Parser) parseCallArguments() []ast.Expression {
-	args := []ast.Expression{}
+// parseArrayLiteral
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: p.curToken}
+	array.Elements = p.parseExpressionList(token.RBRACKET)
+	array.RBracket = p.curToken
 
-	if p.peekTokenIs(token.RPAREN) {
+	return array
+}
+
+// parseHashLiteral A { in expression position starts a hash, blocks only follow if and fn
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.curToken}
+	hash.Pairs = []ast.HashPair{}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	hash.RBrace = p.curToken
+
+	return hash
+}
+
+// parseIndexExpression
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+
+	p.nextToken()
+	exp.Index = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	exp.RBracket = p.curToken
+
+	return exp
+}
+
+// parseExpressionList Parses comma separated expressions up to the end token, used for call arguments and array elements
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+
+	if p.peekTokenIs(end) {
 		p.nextToken()
-		return args
+		return list
 	}
 
 	p.nextToken()
-	args = append(args, p.parseExpression(LOWEST))
+	list = append(list, p.parseExpression(LOWEST))
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
 
-		args = append(args, p.parseExpression(LOWEST))
+		list = append(list, p.parseExpression(LOWEST))
 	}
 
-	if !p.expectPeek(token.RPAREN) {
+	if !p.expectPeek(end) {
 		return nil
 	}
 
-	return args
+	return list
 }
 
 // parseFunctionLiteral
@@ -313,13 +626,16 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
+	// A loop around the function literal doesn't extend into its body
+	loops := p.loops
+	p.loops = 0
 	lit.Body = p.parseBlockStatement()
+	p.loops = loops
 
 	return lit
 }
 
 // noPrefixParseFnError
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.errorAt(diagnostic.ExpectedExpression, p.curToken, "no prefix parse function for %s found", t)
 }
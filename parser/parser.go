@@ -1,9 +1,10 @@
 package parser
 
 import (
-	"fmt"
+	"sort"
 
 	"github.com/seailly/mi/ast"
+	"github.com/seailly/mi/diagnostic"
 	"github.com/seailly/mi/lexer"
 	"github.com/seailly/mi/token"
 )
@@ -11,12 +12,17 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // x = y, x += y
+	OR          // x || y
+	AND         // x && y
 	EQUALS      // ==
-	LESSGREATER // < || >
+	LESSGREATER // <, >, <=, >=, in
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -x || !x
+	POWER       // x ** y, binds tighter than a prefix on its left so -2 ** 2 is -4
 	CALL        // func()
+	INDEX       // array[index]
 )
 
 type prefixParseFn func() ast.Expression
@@ -26,10 +32,18 @@ type infixParseFn func(ast.Expression) ast.Expression
 type Parser struct {
 	l *lexer.Lexer
 
-	errors []string
+	errors []diagnostic.Diagnostic
+
+	// panicking Set once a statement has reported an error, further errors are
+	// dropped until the parser synchronizes on the next statement boundary
+	panicking bool
+	depth     int // nesting of block statements
+	braces    int // balance of { and } tokens read so far
+	loops     int // nesting of loops within the current function
 
 	curToken  token.Token
 	peekToken token.Token
+	comments  []*ast.Comment
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
@@ -37,7 +51,7 @@ type Parser struct {
 
 // New Returns a Parser with setup lexer
 func New(l *lexer.Lexer) *Parser {
-	p := &Parser{l: l, errors: []string{}}
+	p := &Parser{l: l, errors: []diagnostic.Diagnostic{}}
 
 	// Reads two tokens so both curToken and peekToken are set
 	p.nextToken()
@@ -46,6 +60,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
@@ -53,38 +69,129 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.POWER, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.GT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.IN, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
+	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.ASTERISK_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.SLASH_ASSIGN, p.parseAssignExpression)
 
 	return p
 }
 
-// Errors Return list of errors
+// Diagnostics Return the lexical and syntax errors found, ordered by position
+func (p *Parser) Diagnostics() []diagnostic.Diagnostic {
+	diagnostics := append([]diagnostic.Diagnostic{}, p.l.Diagnostics()...)
+	diagnostics = append(diagnostics, p.errors...)
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostic.Less(diagnostics[i], diagnostics[j])
+	})
+
+	return diagnostics
+}
+
+// Errors Return the diagnostics formatted as file:line:col: message
 func (p *Parser) Errors() []string {
-	return p.errors
+	errors := []string{}
+	for _, d := range p.Diagnostics() {
+		errors = append(errors, d.String())
+	}
+
+	return errors
+}
+
+// report Record a syntax error, unless one has already been reported for the current statement
+func (p *Parser) report(d diagnostic.Diagnostic) {
+	if p.panicking {
+		return
+	}
+	p.panicking = true
+
+	p.errors = append(p.errors, d)
+}
+
+// errorAt Record a syntax error spanning tok
+func (p *Parser) errorAt(code string, tok token.Token, format string, a ...interface{}) {
+	p.report(diagnostic.New(code, tok.Pos, tok.End, format, a...))
+}
+
+// synchronize Skip the rest of a statement that failed to parse, leaving the
+// current token at the start of the next statement, on the } closing the
+// enclosing block, or on EOF. level is the brace balance the statement started at
+func (p *Parser) synchronize(level int) {
+	p.panicking = false
+
+	for first := true; !p.curTokenIs(token.EOF); first = false {
+		switch {
+		case p.curTokenIs(token.SEMICOLON) && p.braces == level:
+			p.nextToken()
+			return
+		case p.curTokenIs(token.RBRACE) && p.braces < level && p.depth > 0:
+			return
+		case !first && p.braces == level && isStatementKeyword(p.curToken.Type):
+			return
+		}
+
+		p.nextToken()
+	}
+}
+
+// isStatementKeyword Keywords that can only start a statement
+func isStatementKeyword(t token.TokenType) bool {
+	switch t {
+	case token.MUT, token.LET, token.RETURN, token.WHILE, token.FOR, token.BREAK, token.CONTINUE:
+		return true
+	default:
+		return false
+	}
 }
 
 // peekError
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead",
+	p.errorAt(diagnostic.UnexpectedToken, p.peekToken, "expected next token to be %s, got %s instead",
 		t, p.peekToken.Type)
-
-	p.errors = append(p.errors, msg)
 }
 
 // nextToken
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+
+	switch p.curToken.Type {
+	case token.LBRACE:
+		p.braces++
+	case token.RBRACE:
+		p.braces--
+	}
+
+	// Illegal tokens have already been reported by the lexer, comments are set aside for the program
+	for p.peekToken.Type == token.ILLEGAL || p.peekToken.Type == token.COMMENT {
+		if p.peekToken.Type == token.COMMENT {
+			p.comments = append(p.comments, &ast.Comment{Token: p.peekToken})
+		}
+		p.peekToken = p.l.NextToken()
+	}
 }
 
 // curTokenIs Checks if the current token matches the conditional token
@@ -2,9 +2,11 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/seailly/mi/ast"
+	"github.com/seailly/mi/diagnostic"
 	"github.com/seailly/mi/lexer"
 	"github.com/stretchr/testify/require"
 )
@@ -47,7 +49,7 @@ mut = 10;
 
 	program := p.ParseProgram()
 	require.NotNil(t, program)
-	require.Len(t, p.errors, 3)
+	require.Len(t, p.errors, 2)
 }
 
 func TestReturnStatement(t *testing.T) {
@@ -79,7 +81,7 @@ return 993322;
 
 // checkParserErrors Fails test suite if errors are found in parser
 func checkParserErrors(t *testing.T, p *Parser) {
-	errors := p.errors
+	errors := p.Errors()
 
 	if len(errors) == 0 {
 		return
@@ -198,6 +200,10 @@ func TestParsingInfixExpressions(t *testing.T) {
 		{"5 < 5;", 5, "<", 5},
 		{"5 == 5;", 5, "==", 5},
 		{"5 != 5;", 5, "!=", 5},
+		{"5 <= 5;", 5, "<=", 5},
+		{"5 >= 5;", 5, ">=", 5},
+		{"true && false", true, "&&", false},
+		{"true || false", true, "||", false},
 		{"true == true", true, "==", true},
 		{"true != false", true, "!=", false},
 		{"false == false", false, "==", false},
@@ -245,6 +251,22 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"-a * b",
 			"((-a) * b)",
 		},
+		{
+			"a || b && c || d",
+			"((a || (b && c)) || d)",
+		},
+		{
+			"a < b && c >= d == e",
+			"((a < b) && ((c >= d) == e))",
+		},
+		{
+			"!a && b <= c + 1",
+			"((!a) && (b <= (c + 1)))",
+		},
+		{
+			"x = a || b",
+			"(x = (a || b))",
+		},
 		{
 			"!-a",
 			"(!(-a))",
@@ -337,6 +359,46 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a + b + c * d / f + g)",
 			"add((((a + b) + ((c * d) / f)) + g))",
 		},
+		{
+			"a * [1, 2, 3, 4][b * c] * d",
+			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
+		},
+		{
+			"add(a * b[2], b[1], 2 * [1, 2][1])",
+			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
+		},
+		{
+			"f(x)[0]",
+			"(f(x)[0])",
+		},
+		{
+			"a % b * c",
+			"((a % b) * c)",
+		},
+		{
+			"a + b % c",
+			"(a + (b % c))",
+		},
+		{
+			"a * b ** c",
+			"(a * (b ** c))",
+		},
+		{
+			"a ** b ** c",
+			"(a ** (b ** c))",
+		},
+		{
+			"-a ** b",
+			"(-(a ** b))",
+		},
+		{
+			"a ** -b",
+			"(a ** (-b))",
+		},
+		{
+			"a ** b[0]",
+			"(a ** (b[0]))",
+		},
 	}
 
 	for _, tt := range tests {
@@ -584,3 +646,622 @@ func TestCallExpressionParameterParsing(t *testing.T) {
 		}
 	}
 }
+
+// TestParserErrorPositions
+func TestParserErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"mut x 5;", "main.mi:1:7: expected next token to be =, got INT instead"},
+		{"mut x = 1;\n  add(1, 2", "main.mi:2:11: expected next token to be ), got EOF instead"},
+		{"mut x = );", "main.mi:1:9: no prefix parse function for ) found"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewWithFilename("main.mi", tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors())
+		require.Equal(t, tt.expected, p.Errors()[0])
+	}
+}
+
+// TestNodePositions
+func TestNodePositions(t *testing.T) {
+	input := `mut add = fn(x, y) {
+  x + y;
+};
+add(1, 2)`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Len(t, program.Statements, 2)
+
+	mutStmt := program.Statements[0].(*ast.MutStatement)
+	require.Equal(t, "1:1", mutStmt.Pos().String())
+	require.Equal(t, "3:2", mutStmt.End().String())
+
+	body := mutStmt.Value.(*ast.FunctionLiteral).Body
+	require.Equal(t, "1:20", body.Pos().String())
+	require.Equal(t, "2:3", body.Statements[0].Pos().String())
+
+	call := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	require.Equal(t, "4:1", call.Pos().String())
+	require.Equal(t, "4:10", call.End().String())
+	require.Equal(t, "1:1", program.Pos().String())
+	require.Equal(t, "4:10", program.End().String())
+}
+
+// TestStringLiteralExpression
+func TestStringLiteralExpression(t *testing.T) {
+	input := `"hello \"world\"";`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Len(t, program.Statements, 1)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	require.True(t, ok)
+
+	literal, ok := stmt.Expression.(*ast.StringLiteral)
+	require.True(t, ok)
+	require.Equal(t, `hello "world"`, literal.Value)
+	require.Equal(t, `"hello \"world\""`, literal.String())
+}
+
+// TestLexerErrorsReported
+func TestLexerErrorsReported(t *testing.T) {
+	input := `mut a = "x\q"; mut b = 1 @ 2;`
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	require.Equal(t, []string{
+		"1:11: invalid escape sequence \\q",
+		"1:26: illegal character '@'",
+	}, p.Errors())
+}
+
+// TestParsingArrayLiterals
+func TestParsingArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	require.True(t, ok)
+
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	require.True(t, ok)
+	require.Len(t, array.Elements, 3)
+
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
+	require.Equal(t, "1:18", array.End().String())
+}
+
+// TestParsingEmptyArrayLiterals
+func TestParsingEmptyArrayLiterals(t *testing.T) {
+	input := "[]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	require.True(t, ok)
+	require.Len(t, array.Elements, 0)
+}
+
+// TestParsingIndexExpressions
+func TestParsingIndexExpressions(t *testing.T) {
+	input := "myArray[1 + 1]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	indexExp, ok := stmt.Expression.(*ast.IndexExpression)
+	require.True(t, ok)
+
+	testIdentifier(t, indexExp.Left, "myArray")
+	testInfixExpression(t, indexExp.Index, 1, "+", 1)
+	require.Equal(t, "1:1", indexExp.Pos().String())
+	require.Equal(t, "1:15", indexExp.End().String())
+}
+
+// TestParsingHashLiterals
+func TestParsingHashLiterals(t *testing.T) {
+	input := `{"one": 1, "two": 2, "three": 3}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	require.True(t, ok)
+	require.Len(t, hash.Pairs, 3)
+
+	expected := []struct {
+		key   string
+		value int64
+	}{
+		{"one", 1},
+		{"two", 2},
+		{"three", 3},
+	}
+
+	for i, pair := range hash.Pairs {
+		literal, ok := pair.Key.(*ast.StringLiteral)
+		require.True(t, ok)
+		require.Equal(t, expected[i].key, literal.Value)
+		testIntegerLiteral(t, pair.Value, expected[i].value)
+	}
+}
+
+// TestParsingHashLiteralsMixedKeys
+func TestParsingHashLiteralsMixedKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"{}", "{}"},
+		{`{"a": 1, true: 2, 3: "x",}`, `{"a": 1, true: 2, 3: "x"}`},
+		{`{"one": 0 + 1, "two": 10 - 8}`, `{"one": (0 + 1), "two": (10 - 8)}`},
+		{`"a" in {"a": 1}`, `("a" in {"a": 1})`},
+		{`x + 1 in [1, 2] == true`, `(((x + 1) in [1, 2]) == true)`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Equal(t, tt.expected, program.String())
+	}
+}
+
+// TestParsingHashLiteralErrors
+func TestParsingHashLiteralErrors(t *testing.T) {
+	l := lexer.New(`{"a" 1}`)
+	p := New(l)
+	p.ParseProgram()
+
+	require.NotEmpty(t, p.Errors())
+	require.Equal(t, "1:6: expected next token to be :, got INT instead", p.Errors()[0])
+}
+
+// TestErrorRecovery
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedErrors     []string
+		expectedStatements string
+	}{
+		{
+			"mut x = 5",
+			[]string{},
+			"mut x = 5;",
+		},
+		{
+			"return 5",
+			[]string{},
+			"return 5;",
+		},
+		{
+			"mut x 5;\nmut = 10;\nmut y = 2;",
+			[]string{
+				"1:7: expected next token to be =, got INT instead",
+				"2:5: expected next token to be IDENT, got = instead",
+			},
+			"mut y = 2;",
+		},
+		{
+			"mut f = fn() { mut a 1; a }; mut b = 2;",
+			[]string{"1:22: expected next token to be =, got INT instead"},
+			"mut f = fn() a; mut b = 2;",
+		},
+		{
+			"mut a = (1 + ;\nmut b = 2 +;\nb",
+			[]string{
+				"1:14: no prefix parse function for ; found",
+				"2:12: no prefix parse function for ; found",
+			},
+			"b",
+		},
+		{
+			"} mut a = 1;",
+			[]string{"1:1: no prefix parse function for } found"},
+			"mut a = 1;",
+		},
+		{
+			"if (x) { 1 + }\nmut a = 1;",
+			[]string{"1:14: no prefix parse function for } found"},
+			"if x  mut a = 1;",
+		},
+		{
+			"mut f = fn(x) { x",
+			[]string{"1:18: expected } to close block opened at 1:15, got EOF instead"},
+			"",
+		},
+		{
+			"mut f = fn(1, y) { y }; f(1)",
+			[]string{"1:12: expected next token to be IDENT, got INT instead"},
+			"f(1)",
+		},
+		{
+			"mut a = { mut b = 1; }; mut c = 3;",
+			[]string{"1:11: no prefix parse function for MUT found"},
+			"mut c = 3;",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		require.Equal(t, tt.expectedErrors, p.Errors(), tt.input)
+
+		statements := []string{}
+		for _, stmt := range program.Statements {
+			require.NotNil(t, stmt)
+			statements = append(statements, stmt.String())
+		}
+
+		require.Equal(t, tt.expectedStatements, strings.Join(statements, " "), tt.input)
+	}
+}
+
+// TestDiagnostics
+func TestDiagnostics(t *testing.T) {
+	input := "mut s = \"\\q\";\nmut f = fn(x) {\n  x +"
+
+	l := lexer.NewWithFilename("main.mi", input)
+	p := New(l)
+	p.ParseProgram()
+
+	diagnostics := p.Diagnostics()
+	require.Len(t, diagnostics, 3)
+
+	require.Equal(t, diagnostic.InvalidEscape, diagnostics[0].Code)
+	require.Equal(t, "main.mi:1:10", diagnostics[0].Span.Start.String())
+	require.Equal(t, "main.mi:1:12", diagnostics[0].Span.End.String())
+
+	require.Equal(t, diagnostic.ExpectedExpression, diagnostics[1].Code)
+	require.Equal(t, "main.mi:3:6", diagnostics[1].Span.Start.String())
+
+	require.Equal(t, diagnostic.UnclosedBlock, diagnostics[2].Code)
+	require.Len(t, diagnostics[2].Labels, 1)
+	require.Equal(t, "main.mi:2:15", diagnostics[2].Labels[0].Span.Start.String())
+	require.Equal(t, "block opened here", diagnostics[2].Labels[0].Message)
+	require.NotEmpty(t, diagnostics[2].Hint)
+}
+
+// TestWhileStatement
+func TestWhileStatement(t *testing.T) {
+	input := `while (x < 10) { x; break; continue; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Len(t, program.Statements, 1)
+
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	require.True(t, ok)
+
+	testInfixExpression(t, stmt.Condition, "x", "<", 10)
+	require.Len(t, stmt.Body.Statements, 3)
+
+	_, ok = stmt.Body.Statements[1].(*ast.BreakStatement)
+	require.True(t, ok)
+
+	_, ok = stmt.Body.Statements[2].(*ast.ContinueStatement)
+	require.True(t, ok)
+
+	require.Equal(t, "while (x < 10) xbreak;continue;", stmt.String())
+	require.Equal(t, "1:39", stmt.End().String())
+}
+
+// TestForStatement
+func TestForStatement(t *testing.T) {
+	input := `for (item in [1, 2]) { if (item == 2) { break } item }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Len(t, program.Statements, 1)
+
+	stmt, ok := program.Statements[0].(*ast.ForStatement)
+	require.True(t, ok)
+
+	testIdentifier(t, stmt.Variable, "item")
+	require.Equal(t, "[1, 2]", stmt.Iterable.String())
+	require.Len(t, stmt.Body.Statements, 2)
+}
+
+// TestLoopSemicolons
+func TestLoopSemicolons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"while (c) { c; };", 1},
+		{"for (x in xs) { x };", 1},
+		{"while (c) { break; }; for (x in xs) { x }; c", 3},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Len(t, program.Statements, tt.expected, tt.input)
+	}
+
+	l := lexer.New("fn(xs) { while (true) { break; }; for (x in xs) { x; }; xs }")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	function := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	require.Len(t, function.Body.Statements, 3)
+}
+
+// TestLoopErrors
+func TestLoopErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"break;", "1:1: break outside of a loop"},
+		{"if (true) { continue; }", "1:13: continue outside of a loop"},
+		{"while (true) { mut f = fn() { break; }; }", "1:31: break outside of a loop"},
+		{"for (1 in x) {}", "1:6: expected next token to be IDENT, got INT instead"},
+		{"for (x of y) {}", "1:8: expected next token to be IN, got IDENT instead"},
+		{"while true {}", "1:7: expected next token to be (, got TRUE instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.Equal(t, []string{tt.expected}, p.Errors(), tt.input)
+	}
+}
+
+// TestAssignExpressions
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5", "(x = 5)"},
+		{"x += y * 2", "(x += (y * 2))"},
+		{"x -= 1", "(x -= 1)"},
+		{"x *= 2", "(x *= 2)"},
+		{"x /= 2", "(x /= 2)"},
+		{"a = b = c", "(a = (b = c))"},
+		{"x = a == b", "(x = (a == b))"},
+		{"arr[1] = 2", "((arr[1]) = 2)"},
+		{"h[\"k\"] += 1", "((h[\"k\"]) += 1)"},
+		{"f(x = 1)", "f((x = 1))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Len(t, program.Statements, 1)
+		require.Equal(t, tt.expected, program.String())
+	}
+}
+
+// TestAssignExpressionErrors
+func TestAssignExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 = 2", "1:1: cannot assign to 1"},
+		{"a + b = 2", "1:1: cannot assign to (a + b)"},
+		{"f() += 1", "1:1: cannot assign to f()"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.Equal(t, []string{tt.expected}, p.Errors(), tt.input)
+	}
+}
+
+// TestLetStatements
+func TestLetStatements(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedIdentifier string
+		expectedValue      interface{}
+	}{
+		{"let x = 5;", "x", 5},
+		{"let y = true", "y", true},
+		{"let foobar = y;", "foobar", "y"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Len(t, program.Statements, 1)
+
+		stmt, ok := program.Statements[0].(*ast.LetStatement)
+		require.True(t, ok, "s not *ast.LetStatement. got %T", program.Statements[0])
+		require.Equal(t, tt.expectedIdentifier, stmt.Name.Value)
+		testLiteralExpression(t, stmt.Value, tt.expectedValue)
+	}
+}
+
+// TestFloatLiteralExpression
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"1.5;", 1.5},
+		{"0.001", 0.001},
+		{"1e-3", 0.001},
+		{"2.5E2", 250},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Len(t, program.Statements, 1)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+		require.True(t, ok, "exp not *ast.FloatLiteral. got %T", stmt.Expression)
+		require.Equal(t, tt.expected, literal.Value)
+		require.Equal(t, strings.TrimSuffix(tt.input, ";"), literal.String())
+	}
+}
+
+// TestNumberLiteralErrors
+func TestNumberLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"mut x = 1e999;", "1:9: could not parse \"1e999\" as float"},
+		{"mut x = 1e;", "1:9: malformed number 1e, exponent has no digits"},
+		{"mut x = 0x;", "1:9: malformed number 0x, hexadecimal literal has no digits"},
+		{"mut x = 1__0;", "1:9: malformed number 1__0, _ must separate successive digits"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.Equal(t, []string{tt.expected}, p.Errors(), tt.input)
+	}
+}
+
+// TestPrefixedIntegerLiterals
+func TestPrefixedIntegerLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0xFF", 255},
+		{"0Xab_CD", 0xabcd},
+		{"0o17", 15},
+		{"0b1010", 10},
+		{"0b_1010_1010", 170},
+		{"1_000_000", 1000000},
+		{"017", 15},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+		require.True(t, ok, "exp not *ast.IntegerLiteral. got %T", stmt.Expression)
+		require.Nil(t, literal.Big, tt.input)
+		require.Equal(t, tt.expected, literal.Value, tt.input)
+		require.Equal(t, tt.input, literal.String())
+	}
+
+	l := lexer.New("0xFFFF_FFFF_FFFF_FFFF_FFFF")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	literal := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IntegerLiteral)
+	require.NotNil(t, literal.Big)
+	require.Equal(t, "1208925819614629174706175", literal.Big.String())
+}
+
+// TestBigIntegerLiteral
+func TestBigIntegerLiteral(t *testing.T) {
+	l := lexer.New("123456789012345678901234567890")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+	require.True(t, ok, "exp not *ast.IntegerLiteral. got %T", stmt.Expression)
+	require.NotNil(t, literal.Big)
+	require.Equal(t, "123456789012345678901234567890", literal.Big.String())
+	require.Equal(t, "123456789012345678901234567890", literal.String())
+}
+
+func TestComments(t *testing.T) {
+	input := `// double a number
+mut double = fn(x) {
+  x * 2 /* the factor */
+};
+double(/* inline */ 3) // call`
+
+	program := New(lexer.New(input)).ParseProgram()
+	require.Len(t, program.Statements, 2)
+	require.Empty(t, program.Comments)
+
+	l := lexer.New(input)
+	l.EmitComments()
+	p := New(l)
+	program = p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Equal(t, "mut double = fn(x) (x * 2);double(3)", program.String())
+
+	var comments []string
+	for _, c := range program.Comments {
+		comments = append(comments, c.Pos().String()+" "+c.String())
+	}
+	require.Equal(t, []string{
+		"1:1 // double a number",
+		"3:9 /* the factor */",
+		"5:8 /* inline */",
+		"5:24 // call",
+	}, comments)
+}
@@ -6,17 +6,21 @@ import (
 	"github.com/seailly/mi/object"
 	"io"
 
+	"github.com/seailly/mi/diagnostic"
 	"github.com/seailly/mi/evaluator"
 	"github.com/seailly/mi/lexer"
 	"github.com/seailly/mi/parser"
+	"github.com/seailly/mi/resolver"
 )
 
 const PROMPT = "> "
 
-// Start Run a REPL instance, reading io content into the lexer
+// Start Run a REPL instance evaluating in one line at a time. Each line is parsed as a whole program, so it is
+// read completely before it is lexed
 func Start(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
 	env := object.NewEnvironment()
+	env.SetOutput(out)
 
 	for {
 		fmt.Printf(PROMPT)
@@ -30,13 +34,25 @@ func Start(in io.Reader, out io.Writer) {
 		p := parser.New(l)
 
 		program := p.ParseProgram()
-		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
+		if len(p.Diagnostics()) != 0 {
+			printDiagnostics(out, p.Diagnostics(), line)
+			continue
+		}
+
+		if diagnostics := resolver.Resolve(program, env, evaluator.IsBuiltin); len(diagnostics) != 0 {
+			printDiagnostics(out, diagnostics, line)
 			continue
 		}
 
 		evaluated := evaluator.Eval(program, env)
-		if evaluated != nil {
+		if errObj, ok := evaluated.(*object.Error); ok {
+			printDiagnostics(out, []diagnostic.Diagnostic{errObj.Diagnostic()}, line)
+
+			_, err := io.WriteString(out, errObj.StackTrace())
+			if err != nil {
+				panic("Failed to write string")
+			}
+		} else if evaluated != nil {
 			_, err := io.WriteString(out, evaluated.Inspect())
 			if err != nil {
 				panic("Failed to write string")
@@ -50,9 +66,9 @@ func Start(in io.Reader, out io.Writer) {
 	}
 }
 
-func printParserErrors(out io.Writer, errors []string) {
-	for _, msg := range errors {
-		_, err := io.WriteString(out, "\t"+msg+"\n")
+func printDiagnostics(out io.Writer, diagnostics []diagnostic.Diagnostic, line string) {
+	for _, d := range diagnostics {
+		err := diagnostic.Render(out, d, line)
 		if err != nil {
 			panic("Failed to write string")
 		}
@@ -0,0 +1,316 @@
+package resolver
+
+import (
+	"sort"
+
+	"github.com/seailly/mi/ast"
+	"github.com/seailly/mi/diagnostic"
+	"github.com/seailly/mi/object"
+)
+
+// symbol A variable declared in a scope
+type symbol struct {
+	slot     int
+	decl     *ast.Identifier // first declaration
+	seq      int             // when the declaration took effect, references visited earlier come before it
+	existing bool            // a global bound before this program, by an earlier REPL line or the host
+	let      *ast.Identifier // declaration with let, after which the variable can't change
+	letSeq   int
+	captured bool // referred to from a nested function
+}
+
+// reference An identifier whose variable hasn't been found yet
+type reference struct {
+	ident    *ast.Identifier
+	depth    int // scopes left behind so far
+	seq      int
+	function int  // function literals enclosing the identifier
+	assigned bool // the target of an assignment, which builtins can't be
+}
+
+// scope The variables of the program, a function call or a loop iteration. Blocks of if and while share the
+// scope they are in, as they do in the evaluator
+type scope struct {
+	symbols  map[string]*symbol
+	slots    int
+	function int
+	pending  []*reference // references to resolve once every declaration of the scope is known
+}
+
+// resolver
+type resolver struct {
+	globals   *object.Environment
+	isBuiltin func(name string) bool
+
+	scopes     []*scope
+	seq        int
+	newGlobals []string
+	errors     []diagnostic.Diagnostic
+}
+
+// Resolve Bind every identifier in program to the depth and slot of its variable, size the scopes of functions
+// and loops, and list the variables of those scopes that nested functions capture. Variables declared in globals,
+// by earlier programs or the host, are visible everywhere. Reports names that are never defined, names used before
+// their definition in the same function, and let bindings that are assigned or declared again. Functions may refer
+// to variables of enclosing scopes declared after them, as they run later, which makes mutual recursion possible.
+// The new globals of program are only added to globals when it resolves without errors, and program is then marked
+// resolved
+func Resolve(program *ast.Program, globals *object.Environment, isBuiltin func(name string) bool) []diagnostic.Diagnostic {
+	r := &resolver{globals: globals, isBuiltin: isBuiltin}
+
+	r.openScope(0)
+	r.resolveStatements(program.Statements)
+	r.closeScope()
+
+	sort.SliceStable(r.errors, func(i, j int) bool {
+		return diagnostic.Less(r.errors[i], r.errors[j])
+	})
+
+	if len(r.errors) == 0 {
+		for _, name := range r.newGlobals {
+			globals.Define(name)
+		}
+
+		program.Resolved = true
+	}
+
+	return r.errors
+}
+
+func (r *resolver) resolveStatements(statements []ast.Statement) {
+	for _, s := range statements {
+		r.resolve(s)
+	}
+}
+
+func (r *resolver) resolve(node ast.Node) {
+	switch node := node.(type) {
+	// Statements
+	case *ast.ExpressionStatement:
+		r.resolve(node.Expression)
+
+	case *ast.MutStatement:
+		r.resolve(node.Value)
+		r.declare(node.Name, false)
+
+	case *ast.LetStatement:
+		r.resolve(node.Value)
+		r.declare(node.Name, true)
+
+	case *ast.ReturnStatement:
+		r.resolve(node.ReturnValue)
+
+	case *ast.BlockStatement:
+		r.resolveStatements(node.Statements)
+
+	case *ast.WhileStatement:
+		r.resolve(node.Condition)
+		r.resolve(node.Body)
+
+	case *ast.ForStatement:
+		r.resolve(node.Iterable)
+
+		r.openScope(r.current().function)
+		r.declare(node.Variable, false)
+		r.resolve(node.Body)
+		node.Slots, node.Captured = r.closeScope()
+
+	// Expressions
+	case *ast.Identifier:
+		r.reference(node, false)
+
+	case *ast.PrefixExpression:
+		r.resolve(node.Right)
+
+	case *ast.InfixExpression:
+		r.resolve(node.Left)
+		r.resolve(node.Right)
+
+	case *ast.IfExpression:
+		r.resolve(node.Condition)
+		r.resolve(node.Consequence)
+		if node.Alternative != nil {
+			r.resolve(node.Alternative)
+		}
+
+	case *ast.FunctionLiteral:
+		r.openScope(r.current().function + 1)
+		for _, param := range node.Parameters {
+			r.declare(param, false)
+		}
+		r.resolve(node.Body)
+		node.Slots, node.Captured = r.closeScope()
+
+	case *ast.CallExpression:
+		r.resolve(node.Function)
+		for _, arg := range node.Arguments {
+			r.resolve(arg)
+		}
+
+	case *ast.ArrayLiteral:
+		for _, el := range node.Elements {
+			r.resolve(el)
+		}
+
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			r.resolve(pair.Key)
+			r.resolve(pair.Value)
+		}
+
+	case *ast.IndexExpression:
+		r.resolve(node.Left)
+		r.resolve(node.Index)
+
+	case *ast.AssignExpression:
+		if ident, ok := node.Target.(*ast.Identifier); ok {
+			r.reference(ident, true)
+		} else {
+			r.resolve(node.Target)
+		}
+		r.resolve(node.Value)
+	}
+}
+
+// declare Give the variable of a declaration a slot in the current scope, redeclarations share the first one's slot.
+// A let binding can't be declared again in its scope, though it may be shadowed in a function or loop
+func (r *resolver) declare(ident *ast.Identifier, immutable bool) {
+	s := r.current()
+
+	sym, ok := s.symbols[ident.Value]
+	if ok && sym.let != nil {
+		r.errors = append(r.errors, diagnostic.New(diagnostic.ImmutableBinding, ident.Pos(), ident.End(),
+			"cannot redeclare immutable binding %s", ident.Value).
+			WithLabel(sym.let.Pos(), sym.let.End(), "declared with let here"))
+	}
+
+	if !ok {
+		sym = &symbol{decl: ident}
+
+		if len(r.scopes) > 1 {
+			sym.slot = s.slots
+		} else if slot, ok := r.globals.Slot(ident.Value); ok {
+			sym.slot = slot
+			sym.existing = true
+		} else {
+			sym.slot = r.globals.Len() + len(r.newGlobals)
+			r.newGlobals = append(r.newGlobals, ident.Value)
+		}
+
+		s.symbols[ident.Value] = sym
+		s.slots++
+	}
+
+	r.seq++
+	if !ok {
+		sym.seq = r.seq
+	}
+
+	if immutable && sym.let == nil {
+		sym.let = ident
+		sym.letSeq = r.seq
+	}
+
+	bind(ident, 0, sym.slot)
+}
+
+// reference Bind ident to a variable already declared in the current scope, or leave it until the scope closes.
+// assigned is set for the target of an assignment
+func (r *resolver) reference(ident *ast.Identifier, assigned bool) {
+	r.seq++
+
+	s := r.current()
+	ref := &reference{ident: ident, seq: r.seq, function: s.function, assigned: assigned}
+
+	if sym, ok := s.symbols[ident.Value]; ok {
+		bind(ident, 0, sym.slot)
+		r.checkAssignable(ref, sym, s)
+	} else {
+		s.pending = append(s.pending, ref)
+	}
+}
+
+func (r *resolver) openScope(function int) {
+	r.scopes = append(r.scopes, &scope{symbols: make(map[string]*symbol), function: function})
+}
+
+// closeScope Settle the pending references of the current scope now all its declarations are known, passing the
+// rest to the enclosing scope. Returns the number of slots the scope needs, and the slots nested functions refer to
+func (r *resolver) closeScope() (int, []int) {
+	s := r.current()
+	r.scopes = r.scopes[:len(r.scopes)-1]
+
+	for _, ref := range s.pending {
+		if sym, ok := s.symbols[ref.ident.Value]; ok {
+			if sym.seq > ref.seq && !sym.existing && ref.function == s.function {
+				r.errors = append(r.errors, diagnostic.New(diagnostic.UseBeforeDefinition, ref.ident.Pos(),
+					ref.ident.End(), "use of %s before its definition", ref.ident.Value).
+					WithLabel(sym.decl.Pos(), sym.decl.End(), "defined here"))
+			}
+
+			bind(ref.ident, ref.depth, sym.slot)
+			r.checkAssignable(ref, sym, s)
+			if ref.function != s.function {
+				sym.captured = true
+			}
+			continue
+		}
+
+		if len(r.scopes) > 0 {
+			ref.depth++
+			r.current().pending = append(r.current().pending, ref)
+			continue
+		}
+
+		r.resolveGlobal(ref)
+	}
+
+	var captured []int
+	for _, sym := range s.symbols {
+		if sym.captured {
+			captured = append(captured, sym.slot)
+		}
+	}
+	sort.Ints(captured)
+
+	return s.slots, captured
+}
+
+// checkAssignable Report an assignment to a variable of s declared with let before it, or from a function that may
+// run once the let binding has been made
+func (r *resolver) checkAssignable(ref *reference, sym *symbol, s *scope) {
+	if !ref.assigned || sym.let == nil || sym.letSeq > ref.seq && ref.function == s.function {
+		return
+	}
+
+	d := diagnostic.New(diagnostic.ImmutableBinding, ref.ident.Pos(), ref.ident.End(),
+		"cannot assign to immutable binding %s", ref.ident.Value)
+	r.errors = append(r.errors, d.WithLabel(sym.let.Pos(), sym.let.End(), "declared with let here").
+		WithHint("declare it with mut to allow reassignment"))
+}
+
+// resolveGlobal Bind a reference no scope of the program declares to a global bound earlier, or a builtin
+func (r *resolver) resolveGlobal(ref *reference) {
+	if slot, ok := r.globals.Slot(ref.ident.Value); ok {
+		bind(ref.ident, ref.depth, slot)
+		return
+	}
+
+	if !ref.assigned && r.isBuiltin(ref.ident.Value) {
+		ref.ident.Resolution = ast.Builtin
+		return
+	}
+
+	r.errors = append(r.errors, diagnostic.New(diagnostic.UndefinedName, ref.ident.Pos(), ref.ident.End(),
+		"undefined name %s", ref.ident.Value))
+}
+
+func (r *resolver) current() *scope {
+	return r.scopes[len(r.scopes)-1]
+}
+
+func bind(ident *ast.Identifier, depth int, slot int) {
+	ident.Resolution = ast.Variable
+	ident.Depth = depth
+	ident.Slot = slot
+}
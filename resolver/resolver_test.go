@@ -0,0 +1,189 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/seailly/mi/ast"
+	"github.com/seailly/mi/diagnostic"
+	"github.com/seailly/mi/lexer"
+	"github.com/seailly/mi/object"
+	"github.com/seailly/mi/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func isBuiltin(name string) bool {
+	return name == "len"
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors(), input)
+
+	return program
+}
+
+func TestResolveBindings(t *testing.T) {
+	input := `mut a = 1;
+mut f = fn(b) {
+  mut c = a + b;
+  for (x in [c]) { mut y = x; y + c + a }
+};
+len`
+
+	program := parse(t, input)
+	require.Empty(t, Resolve(program, object.NewEnvironment(), isBuiltin))
+
+	fn := program.Statements[1].(*ast.MutStatement).Value.(*ast.FunctionLiteral)
+	require.Equal(t, 2, fn.Slots)
+
+	c := fn.Body.Statements[0].(*ast.MutStatement)
+	requireBinding(t, c.Name, 0, 1)
+
+	sum := c.Value.(*ast.InfixExpression)
+	requireBinding(t, sum.Left.(*ast.Identifier), 1, 0)
+	requireBinding(t, sum.Right.(*ast.Identifier), 0, 0)
+
+	loop := fn.Body.Statements[1].(*ast.ForStatement)
+	require.Equal(t, 2, loop.Slots)
+	requireBinding(t, loop.Variable, 0, 0)
+	requireBinding(t, loop.Iterable.(*ast.ArrayLiteral).Elements[0].(*ast.Identifier), 0, 1)
+
+	// (y + c) + a
+	body := loop.Body.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.InfixExpression)
+	inner := body.Left.(*ast.InfixExpression)
+	requireBinding(t, inner.Left.(*ast.Identifier), 0, 1)
+	requireBinding(t, inner.Right.(*ast.Identifier), 1, 1)
+	requireBinding(t, body.Right.(*ast.Identifier), 2, 0)
+
+	builtin := program.Statements[2].(*ast.ExpressionStatement).Expression.(*ast.Identifier)
+	require.Equal(t, ast.Builtin, builtin.Resolution)
+}
+
+func requireBinding(t *testing.T, ident *ast.Identifier, depth int, slot int) {
+	t.Helper()
+
+	require.Equal(t, ast.Variable, ident.Resolution, ident.Value)
+	require.Equal(t, depth, ident.Depth, ident.Value)
+	require.Equal(t, slot, ident.Slot, ident.Value)
+}
+
+func TestResolveCaptured(t *testing.T) {
+	program := parse(t, "mut f = fn(a, b) { mut c = 1; for (x in [a]) { mut y = x; fn() { fn() { b + x } } }; fn() { c } }")
+	require.Empty(t, Resolve(program, object.NewEnvironment(), isBuiltin))
+
+	fn := program.Statements[0].(*ast.MutStatement).Value.(*ast.FunctionLiteral)
+	require.Equal(t, []int{1, 2}, fn.Captured)
+
+	loop := fn.Body.Statements[1].(*ast.ForStatement)
+	require.Equal(t, []int{0}, loop.Captured)
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+	}{
+		{"mut a = 1; a + b", []string{"1:16: undefined name b"}},
+		{"x = 5", []string{"1:1: undefined name x"}},
+		{"len = 5", []string{"1:1: undefined name len"}},
+		{"mut f = fn() { g() + h }", []string{"1:16: undefined name g", "1:22: undefined name h"}},
+		{"x; mut x = 1", []string{"1:1: use of x before its definition"}},
+		{"mut x = x + 1", []string{"1:9: use of x before its definition"}},
+		{"mut a = 1; fn() { a; mut a = 2 }", []string{"1:19: use of a before its definition"}},
+		{"for (i in [1]) { y; } mut y = 1", []string{"1:18: use of y before its definition"}},
+		{"mut len = len", []string{"1:11: use of len before its definition"}},
+		{"fn(x) { fn() { x } }; x", []string{"1:23: undefined name x"}},
+		// Functions run after the declarations they refer to
+		{"mut even = fn(n) { if (n == 0) { true } else { odd(n - 1) } }; mut odd = fn(n) { even(n) }", []string{}},
+		{"mut f = fn() { g() }; f(); mut g = fn() { 1 }", []string{}},
+		{"mut a = 1; if (a) { mut b = 2 }; b", []string{}},
+		{"mut i = 0; while (i < 3) { mut j = i; i = j + 1 }", []string{}},
+	}
+
+	for _, tt := range tests {
+		diagnostics := Resolve(parse(t, tt.input), object.NewEnvironment(), isBuiltin)
+
+		errors := []string{}
+		for _, d := range diagnostics {
+			errors = append(errors, d.String())
+		}
+
+		require.Equal(t, tt.expectedErrors, errors, tt.input)
+	}
+}
+
+func TestResolveUseBeforeDefinitionDiagnostic(t *testing.T) {
+	diagnostics := Resolve(parse(t, "x + 1;\nmut x = 2;"), object.NewEnvironment(), isBuiltin)
+
+	require.Len(t, diagnostics, 1)
+	require.Equal(t, diagnostic.UseBeforeDefinition, diagnostics[0].Code)
+	require.Equal(t, "defined here", diagnostics[0].Labels[0].Message)
+	require.Equal(t, "2:5", diagnostics[0].Labels[0].Span.Start.String())
+}
+
+func TestResolveImmutable(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+	}{
+		{"let x = 1; x = 2;", []string{"1:12: cannot assign to immutable binding x"}},
+		{"let x = 1; mut f = fn() { x += 1 };", []string{"1:27: cannot assign to immutable binding x"}},
+		{"mut f = fn() { x = 2 }; let x = 1;", []string{"1:16: cannot assign to immutable binding x"}},
+		{"let x = 1;\nfor (i in [1]) { x = i }", []string{"2:18: cannot assign to immutable binding x"}},
+		{"let x = 1; x = 2; x = 3;", []string{"1:12: cannot assign to immutable binding x", "1:19: cannot assign to immutable binding x"}},
+		{"let a = 1; mut a = 2", []string{"1:16: cannot redeclare immutable binding a"}},
+		{"let a = 1; let a = 2", []string{"1:16: cannot redeclare immutable binding a"}},
+		{"mut x = 1; if (x) { let x = 2 }; x = 3;", []string{"1:34: cannot assign to immutable binding x"}},
+		{"mut x = 1; x = 2; let x = 3", []string{}},
+		{"let x = [1]; x[0] = 2;", []string{}},
+		{"let x = 1; mut f = fn(x) { x = 2 };", []string{}},
+		{"let x = 1; mut f = fn() { mut x = 0; x = 2 };", []string{}},
+		{"let x = 1; for (x in [1]) { x = 2 }", []string{}},
+	}
+
+	for _, tt := range tests {
+		diagnostics := Resolve(parse(t, tt.input), object.NewEnvironment(), isBuiltin)
+
+		errors := []string{}
+		for _, d := range diagnostics {
+			errors = append(errors, d.String())
+		}
+
+		require.Equal(t, tt.expectedErrors, errors, tt.input)
+	}
+}
+
+func TestResolveImmutableDiagnostic(t *testing.T) {
+	diagnostics := Resolve(parse(t, "let x = 1;\nx = 2;"), object.NewEnvironment(), isBuiltin)
+
+	require.Len(t, diagnostics, 1)
+	require.Equal(t, diagnostic.ImmutableBinding, diagnostics[0].Code)
+	require.Equal(t, "1:5", diagnostics[0].Labels[0].Span.Start.String())
+	require.Equal(t, "declared with let here", diagnostics[0].Labels[0].Message)
+	require.Equal(t, "declare it with mut to allow reassignment", diagnostics[0].Hint)
+}
+
+func TestResolveAcrossPrograms(t *testing.T) {
+	globals := object.NewEnvironment()
+	globals.Set("args", &object.Array{})
+
+	require.Empty(t, Resolve(parse(t, "mut a = args"), globals, isBuiltin))
+
+	slot, ok := globals.Slot("a")
+	require.True(t, ok)
+	require.Equal(t, 1, slot)
+
+	// Earlier globals are visible, and can be redeclared after being used
+	program := parse(t, "a; mut a = 2; mut b = a")
+	require.Empty(t, Resolve(program, globals, isBuiltin))
+	requireBinding(t, program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.Identifier), 0, 1)
+
+	// A program with errors adds no globals
+	require.NotEmpty(t, Resolve(parse(t, "mut c = 1; missing"), globals, isBuiltin))
+	_, ok = globals.Slot("c")
+	require.False(t, ok)
+	require.Equal(t, 3, globals.Len())
+}
@@ -0,0 +1,118 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/seailly/mi/ast"
+	"github.com/seailly/mi/compiler"
+	"github.com/seailly/mi/diagnostic"
+	"github.com/seailly/mi/evaluator"
+	"github.com/seailly/mi/lexer"
+	"github.com/seailly/mi/object"
+	"github.com/seailly/mi/parser"
+	"github.com/seailly/mi/resolver"
+	"github.com/seailly/mi/vm"
+)
+
+// Exit codes returned by Run and RunFile
+const (
+	ExitSuccess      = 0
+	ExitRuntimeError = 1 // evaluation produced an error, or the file could not be read
+	ExitParseError   = 2 // the script failed to parse or refers to undefined names
+)
+
+// Engine Executes a parsed program, returning its value or an *object.Error
+type Engine func(program *ast.Program, env *object.Environment) object.Object
+
+// Evaluate Run programs on the tree walking evaluator
+func Evaluate(program *ast.Program, env *object.Environment) object.Object {
+	return evaluator.Eval(program, env)
+}
+
+// Bytecode Compile resolved programs and run them on the vm, which gives the same results as the evaluator
+func Bytecode(program *ast.Program, env *object.Environment) object.Object {
+	c := compiler.New()
+	if err := c.Compile(program); err != nil {
+		return &object.Error{Message: err.Error()}
+	}
+
+	return vm.New(c.Bytecode(), env).Run()
+}
+
+// RunFile Run the script at path, see Run. The script is lexed as it is read, and only read again when there
+// are errors to show
+func RunFile(engine Engine, path string, args []string, errOut io.Writer) int {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(errOut, "mi: %s\n", err)
+		return ExitRuntimeError
+	}
+	defer f.Close()
+
+	source := func() string {
+		input, _ := os.ReadFile(path)
+		return string(input)
+	}
+
+	return run(engine, lexer.NewReader(path, f), source, args, errOut)
+}
+
+// Run Lex, parse and execute a whole script with engine, writing any errors to errOut and returning the exit
+// code. args are exposed to the script as the `args` array of strings
+func Run(engine Engine, filename string, input string, args []string, errOut io.Writer) int {
+	source := func() string {
+		return input
+	}
+
+	return run(engine, lexer.NewWithFilename(filename, input), source, args, errOut)
+}
+
+// run Run the script l reads, source gives its text for showing errors
+func run(engine Engine, l *lexer.Lexer, source func() string, args []string, errOut io.Writer) int {
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+	if diagnostics := p.Diagnostics(); len(diagnostics) != 0 {
+		render(errOut, diagnostics, source())
+		return ExitParseError
+	}
+
+	env := object.NewEnvironment()
+	env.Set("args", argsArray(args))
+
+	if diagnostics := resolver.Resolve(program, env, evaluator.IsBuiltin); len(diagnostics) != 0 {
+		render(errOut, diagnostics, source())
+		return ExitParseError
+	}
+
+	evaluated := engine(program, env)
+	if errObj, ok := evaluated.(*object.Error); ok {
+		diagnostic.Render(errOut, errObj.Diagnostic(), source())
+		fmt.Fprint(errOut, errObj.StackTrace())
+		return ExitRuntimeError
+	}
+
+	return ExitSuccess
+}
+
+// render Write diagnostics to errOut separated by blank lines
+func render(errOut io.Writer, diagnostics []diagnostic.Diagnostic, input string) {
+	for i, d := range diagnostics {
+		if i > 0 {
+			fmt.Fprintln(errOut)
+		}
+		diagnostic.Render(errOut, d, input)
+	}
+}
+
+// argsArray
+func argsArray(args []string) *object.Array {
+	elements := make([]object.Object, len(args))
+	for i, arg := range args {
+		elements[i] = &object.String{Value: arg}
+	}
+
+	return &object.Array{Elements: elements}
+}
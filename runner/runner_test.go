@@ -0,0 +1,95 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedCode   int
+		expectedErrOut string
+	}{
+		{"mut a = 5;\nmut b = a * 2;\nb;", ExitSuccess, ""},
+		{"#!/usr/bin/env mi\nmut a = 5;", ExitSuccess, ""},
+		{"mut a = 5;\nmut b 10;", ExitParseError, `error[E0100]: expected next token to be =, got INT instead
+ --> main.mi:2:7
+  |
+2 | mut b 10;
+  |       ^^
+`},
+		{"mut a = 5 +;\nmut b 10;", ExitParseError, `error[E0101]: no prefix parse function for ; found
+ --> main.mi:1:12
+  |
+1 | mut a = 5 +;
+  |            ^
+
+error[E0100]: expected next token to be =, got INT instead
+ --> main.mi:2:7
+  |
+2 | mut b 10;
+  |       ^^
+`},
+		{"mut a = 5;\na + b;", ExitParseError, `error[E0200]: undefined name b
+ --> main.mi:2:5
+  |
+2 | a + b;
+  |     ^
+`},
+		{"mut a = 5;\na + true;", ExitRuntimeError, `error[E1000]: type mismatch: INTEGER + BOOLEAN
+ --> main.mi:2:3
+  |
+2 | a + true;
+  |   ^
+`},
+		{"mut add = fn(a, b) {\n  a + b\n};\nadd(1, true);", ExitRuntimeError, `error[E1000]: type mismatch: INTEGER + BOOLEAN
+ --> main.mi:2:5
+  |
+2 |   a + b
+  |     ^
+	at add (main.mi:4:1)
+`},
+	}
+
+	for _, engine := range []Engine{Evaluate, Bytecode} {
+		for _, tt := range tests {
+			var errOut bytes.Buffer
+
+			code := Run(engine, "main.mi", tt.input, nil, &errOut)
+			require.Equal(t, tt.expectedCode, code)
+			require.Equal(t, tt.expectedErrOut, errOut.String())
+		}
+	}
+}
+
+func TestRun_Args(t *testing.T) {
+	var errOut bytes.Buffer
+
+	code := Run(Evaluate, "main.mi", `if (args[1] == "b") { args[2] + true }`, []string{"a", "b", "c"}, &errOut)
+	require.Equal(t, ExitRuntimeError, code)
+	require.Contains(t, errOut.String(), "error[E1000]: type mismatch: STRING + BOOLEAN\n --> main.mi:1:31\n")
+}
+
+func TestRunFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.mi")
+	require.NoError(t, os.WriteFile(path, []byte("mut a = 1;\n-true;\n"), 0o644))
+
+	var errOut bytes.Buffer
+	code := RunFile(Bytecode, path, nil, &errOut)
+
+	require.Equal(t, ExitRuntimeError, code)
+	require.Equal(t, "error[E1000]: unknown operator: -BOOLEAN\n --> "+path+":2:1\n  |\n2 | -true;\n  | ^^^^^\n", errOut.String())
+}
+
+func TestRunFile_Missing(t *testing.T) {
+	var errOut bytes.Buffer
+	code := RunFile(Evaluate, filepath.Join(t.TempDir(), "missing.mi"), nil, &errOut)
+
+	require.Equal(t, ExitRuntimeError, code)
+	require.Contains(t, errOut.String(), "missing.mi")
+}
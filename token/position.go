@@ -0,0 +1,33 @@
+package token
+
+import "fmt"
+
+// Position A location in the source input
+type Position struct {
+	Filename string
+	Offset   int // byte offset, starting at 0
+	Line     int // line number, starting at 1
+	Column   int // column number in runes, starting at 1
+}
+
+// IsValid Reports whether the position has been set
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String Formats the position as file:line:col, dropping the parts that are unknown
+func (p Position) String() string {
+	s := p.Filename
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+
+	if s == "" {
+		s = "-"
+	}
+
+	return s
+}
@@ -7,6 +7,8 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position // position of the first character of the token
+	End     Position // position immediately after the token
 }
 
 const (
@@ -19,12 +21,24 @@ const (
 	IDENT = "IDENT" // add, foobar, x, y, ...
 	// Int
 	INT = "INT" // 1343456”
+	// Float
+	FLOAT = "FLOAT" // 1.5, 1e-3
+	// String
+	STRING = "STRING" // "foo bar"
 
 	// Operators
 
+	// COMMENT A // or /* */ comment, only produced when the lexer is asked to keep comments
+	COMMENT = "COMMENT"
+
 	// Assign
 	ASSIGN = "="
 
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
+
 	// Plus
 	PLUS = "+"
 
@@ -32,13 +46,20 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
+	POWER    = "**"
 
-	LT = "<"
-	GT = ">"
+	LT    = "<"
+	GT    = ">"
+	LT_EQ = "<="
+	GT_EQ = ">="
 
 	EQ     = "=="
 	NOT_EQ = "!="
 
+	AND = "&&"
+	OR  = "||"
+
 	// Delimiters
 
 	// Comma
@@ -46,12 +67,16 @@ const (
 
 	// Semicolon
 	SEMICOLON = ";"
+	COLON     = ":"
 
 	LPAREN = "("
 	RPAREN = ")"
 	LBRACE = "{"
 	RBRACE = "}"
 
+	LBRACKET = "["
+	RBRACKET = "]"
+
 	// Keywords
 
 	// Function
@@ -59,23 +84,38 @@ const (
 
 	// Mut
 	MUT = "MUT"
+	// Let
+	LET = "LET"
 
 	FALSE  = "FALSE"
 	TRUE   = "TRUE"
 	RETURN = "RETURN"
 	IF     = "IF"
 	ELSE   = "ELSE"
+	IN     = "IN"
+
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
 )
 
 // keywords
 var keywords = map[string]TokenType{
 	"fn":     FUNCTION,
 	"mut":    MUT,
+	"let":    LET,
 	"true":   TRUE,
 	"false":  FALSE,
 	"return": RETURN,
 	"if":     IF,
 	"else":   ELSE,
+	"in":     IN,
+
+	"while":    WHILE,
+	"for":      FOR,
+	"break":    BREAK,
+	"continue": CONTINUE,
 }
 
 // LookupIdent Find keyword TokenType by string
@@ -0,0 +1,46 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/seailly/mi/compiler"
+	"github.com/seailly/mi/evaluator"
+	"github.com/seailly/mi/lexer"
+	"github.com/seailly/mi/object"
+	"github.com/seailly/mi/parser"
+	"github.com/seailly/mi/resolver"
+)
+
+const benchmarkInput = `
+mut fib = fn(n) { if (n < 2) { return n }; fib(n - 1) + fib(n - 2) };
+mut sum = 0;
+mut i = 0;
+while (i < 100000) { sum += i % 7; i += 1 }
+for (x in range(100000)) { sum += x }
+fib(20) + sum
+`
+
+func BenchmarkEvaluator(b *testing.B) {
+	program := parser.New(lexer.New(benchmarkInput)).ParseProgram()
+	env := object.NewEnvironment()
+	resolver.Resolve(program, env, evaluator.IsBuiltin)
+
+	for i := 0; i < b.N; i++ {
+		evaluator.Eval(program, env)
+	}
+}
+
+func BenchmarkVM(b *testing.B) {
+	program := parser.New(lexer.New(benchmarkInput)).ParseProgram()
+	env := object.NewEnvironment()
+	resolver.Resolve(program, env, evaluator.IsBuiltin)
+
+	c := compiler.New()
+	if err := c.Compile(program); err != nil {
+		b.Fatal(err)
+	}
+
+	for i := 0; i < b.N; i++ {
+		New(c.Bytecode(), env).Run()
+	}
+}
@@ -0,0 +1,128 @@
+package vm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/seailly/mi/compiler"
+	"github.com/seailly/mi/evaluator"
+	"github.com/seailly/mi/lexer"
+	"github.com/seailly/mi/object"
+	miparser "github.com/seailly/mi/parser"
+	"github.com/seailly/mi/resolver"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParity Every program in the evaluator's tests gives the same result, output, error position and stack trace
+// when compiled. Programs that don't finish within parityMaxSteps are left out
+const parityMaxSteps = 1000000
+
+// parityPrograms Programs the engines have disagreed on that the evaluator's tests can't run as they are
+var parityPrograms = []string{
+	"fn() {}()",
+	"mut x = fn() {}(); x;",
+	"mut x = fn() {}(); x = 1; x",
+	"mut f = fn() { mut a = 1; }; f()",
+	"if (true) { let a = 1; }",
+	"puts(fn() {}()); type(if (true) {})",
+	"len(fn() {}())",
+	"mut i = 0; while (i < 5) { i += 1; mut x = if (i == 2) { break; } else { i }; puts(x); } i",
+	"mut i = 0; while (i < 5) { i += 1; puts([if (i == 2) { continue; } else { i }]); } i",
+	"mut n = 0; for (c in [false, true, false]) { n += 1 + if (c) { continue; } else { 2 }; } n",
+	"for (x in [1, 2]) { puts({x: if (x == 2) { break; } else { -x }}); }",
+	"mut f = fn(x) { 1 + if (x) { return 10; } else { 2 } }; f(true) + f(false)",
+}
+
+func TestParity(t *testing.T) {
+	files, err := filepath.Glob("../evaluator/*_test.go")
+	require.NoError(t, err)
+
+	ran := 0
+	for _, input := range append(stringLiterals(t, files), parityPrograms...) {
+		p := miparser.New(lexer.New(input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			continue
+		}
+
+		var expectedOut bytes.Buffer
+		env := object.NewEnvironment()
+		env.SetOutput(&expectedOut)
+		if len(resolver.Resolve(program, env, evaluator.IsBuiltin)) != 0 {
+			continue
+		}
+
+		expected := evaluator.EvalContext(context.Background(), program, env, evaluator.Limits{MaxSteps: parityMaxSteps})
+		if err, ok := expected.(*object.Error); ok && errors.Is(err.Cause, evaluator.ErrStepLimit) {
+			continue
+		}
+
+		actual, actualOut := runOutput(t, input)
+		ran++
+
+		require.Equal(t, inspect(expected), inspect(actual), input)
+		require.Equal(t, expectedOut.String(), actualOut, input)
+
+		if expectedErr, ok := expected.(*object.Error); ok {
+			actualErr := actual.(*object.Error)
+			require.Equal(t, expectedErr.End, actualErr.End, input)
+			require.Equal(t, expectedErr.Trace, actualErr.Trace, input)
+		}
+	}
+
+	require.Greater(t, ran, 100)
+}
+
+// runOutput Compile and run input like run, returning what it wrote with puts and print as well
+func runOutput(t *testing.T, input string) (object.Object, string) {
+	t.Helper()
+
+	program := miparser.New(lexer.New(input)).ParseProgram()
+
+	var out bytes.Buffer
+	env := object.NewEnvironment()
+	env.SetOutput(&out)
+	require.Empty(t, resolver.Resolve(program, env, evaluator.IsBuiltin), input)
+
+	c := compiler.New()
+	require.NoError(t, c.Compile(program), input)
+
+	return New(c.Bytecode(), env).Run(), out.String()
+}
+
+// stringLiterals The string literals in the given Go files
+func stringLiterals(t *testing.T, files []string) []string {
+	var literals []string
+
+	fset := token.NewFileSet()
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		require.NoError(t, err)
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				if s, err := strconv.Unquote(lit.Value); err == nil {
+					literals = append(literals, s)
+				}
+			}
+			return true
+		})
+	}
+
+	return literals
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return "<nil>"
+	}
+
+	return obj.Inspect()
+}
@@ -0,0 +1,594 @@
+package vm
+
+import (
+	"context"
+	"math"
+
+	"github.com/seailly/mi/code"
+	"github.com/seailly/mi/compiler"
+	"github.com/seailly/mi/evaluator"
+	"github.com/seailly/mi/object"
+	"github.com/seailly/mi/token"
+)
+
+// StackSize The initial size of the value stack, it grows as needed
+const StackSize = 2048
+
+// operators Infix operators by opcode, applied with the evaluator's semantics
+var operators = [...]string{
+	code.OpAdd:          "+",
+	code.OpSub:          "-",
+	code.OpMul:          "*",
+	code.OpDiv:          "/",
+	code.OpMod:          "%",
+	code.OpPow:          "**",
+	code.OpEqual:        "==",
+	code.OpNotEqual:     "!=",
+	code.OpGreaterThan:  ">",
+	code.OpLessThan:     "<",
+	code.OpGreaterEqual: ">=",
+	code.OpLessEqual:    "<=",
+	code.OpIn:           "in",
+}
+
+// Integers from minSmallInt to maxSmallInt are preallocated, the integer fast paths share them instead of
+// allocating results that common loops and counters produce over and over
+const (
+	minSmallInt = -128
+	maxSmallInt = 1023
+)
+
+var smallInts = func() []*object.Integer {
+	ints := make([]*object.Integer, maxSmallInt-minSmallInt+1)
+	for i := range ints {
+		ints[i] = &object.Integer{Value: int64(i + minSmallInt)}
+	}
+
+	return ints
+}()
+
+// Frame A call in progress
+type Frame struct {
+	cl       *object.Closure
+	ip       int
+	base     int // first stack slot of the call's locals, the callee sits just below it
+	callSite token.Position
+}
+
+// VM Runs compiled bytecode
+type VM struct {
+	constants []object.Object
+	names     []string
+	globals   *object.Environment
+
+	stack []object.Object
+	sp    int // always points to the next free slot, the top of the stack is stack[sp-1]
+
+	frames []Frame
+	budget *evaluator.Budget
+}
+
+// New A vm running bytecode with its globals in env
+func New(bytecode *compiler.Bytecode, env *object.Environment) *VM {
+	main := &object.Closure{Fn: &object.CompiledFunction{
+		Instructions: bytecode.Instructions,
+		Spans:        bytecode.Spans,
+		Locals:       bytecode.Locals,
+	}}
+
+	size := StackSize
+	if bytecode.Locals > size {
+		size = bytecode.Locals
+	}
+
+	return &VM{
+		constants: bytecode.Constants,
+		names:     bytecode.Names,
+		globals:   env,
+		stack:     make([]object.Object, size),
+		sp:        bytecode.Locals,
+		frames:    []Frame{{cl: main}},
+	}
+}
+
+// Run Execute the program, returning the value of its last statement like evaluator.Eval does.
+// Errors are returned as *object.Error with their position and stack trace
+func (vm *VM) Run() object.Object {
+	return vm.RunContext(context.Background(), evaluator.Limits{})
+}
+
+// RunContext Execute the program like Run, stopping with an error when ctx is done or a limit is exceeded, see
+// evaluator.EvalContext
+func (vm *VM) RunContext(ctx context.Context, limits evaluator.Limits) (result object.Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = evaluator.InternalError(r)
+		}
+	}()
+
+	vm.budget = evaluator.NewBudget(ctx, limits)
+	if err := vm.budget.Check(); err != nil {
+		return err
+	}
+
+	frame := &vm.frames[0]
+	ins := frame.cl.Fn.Instructions
+
+	for frame.ip < len(ins) {
+		start := frame.ip
+		op := code.Opcode(ins[start])
+		frame.ip++
+
+		if err := vm.budget.Step(); err != nil {
+			return vm.raise(err, start)
+		}
+
+		switch op {
+		case code.OpConstant:
+			vm.push(vm.constants[vm.readUint16(frame, ins)])
+
+		case code.OpNull:
+			vm.push(evaluator.NULL)
+
+		case code.OpTrue:
+			vm.push(evaluator.TRUE)
+
+		case code.OpFalse:
+			vm.push(evaluator.FALSE)
+
+		case code.OpPop:
+			vm.sp--
+
+		case code.OpPopN:
+			vm.sp -= vm.readUint16(frame, ins)
+
+		case code.OpDup2:
+			vm.push(vm.stack[vm.sp-2])
+			vm.push(vm.stack[vm.sp-2])
+
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMod, code.OpPow,
+			code.OpEqual, code.OpNotEqual, code.OpGreaterThan, code.OpLessThan, code.OpGreaterEqual, code.OpLessEqual,
+			code.OpIn:
+			right := vm.pop()
+			left := vm.pop()
+
+			if res := integerOperation(op, left, right); res != nil {
+				vm.push(res)
+				continue
+			}
+
+			res := evaluator.InfixOperator(operators[op], left, right)
+			if err, ok := res.(*object.Error); ok {
+				return vm.raise(err, start)
+			}
+			vm.push(res)
+
+		case code.OpMinus, code.OpBang:
+			operator := "-"
+			if op == code.OpBang {
+				operator = "!"
+			}
+
+			res := evaluator.PrefixOperator(operator, vm.pop())
+			if err, ok := res.(*object.Error); ok {
+				return vm.raise(err, start)
+			}
+			vm.push(res)
+
+		case code.OpJump:
+			frame.ip = vm.readUint16(frame, ins)
+
+		case code.OpJumpNotTruthy:
+			target := vm.readUint16(frame, ins)
+			if !evaluator.IsTruthy(vm.pop()) {
+				frame.ip = target
+			}
+
+		case code.OpGetVar:
+			kind := vm.readUint8(frame, ins)
+			slot := vm.readUint16(frame, ins)
+			name := vm.readUint16(frame, ins)
+
+			var val object.Object
+			if kind == code.VarGlobal {
+				val = vm.globals.Load(0, slot)
+			} else {
+				val = vm.variable(frame, kind, slot)
+			}
+
+			if val == nil {
+				return vm.raise(evaluator.Unbound(vm.names[name]), start)
+			}
+			vm.push(val)
+
+		case code.OpGetBuiltin:
+			res := evaluator.Builtin(vm.names[vm.readUint16(frame, ins)])
+			if err, ok := res.(*object.Error); ok {
+				return vm.raise(err, start)
+			}
+			vm.push(res)
+
+		case code.OpSetLocal:
+			vm.setLocal(frame, vm.readUint16(frame, ins), vm.pop())
+
+		case code.OpDeclare:
+			kind := vm.readUint8(frame, ins)
+			slot := vm.readUint16(frame, ins)
+			name := vm.names[vm.readUint16(frame, ins)]
+			mutable := vm.readUint8(frame, ins) == 1
+
+			// Only globals are checked here, the resolver rejects redeclaring a let binding within a program
+			if kind == code.VarGlobal {
+				if err, ok := evaluator.Declare(vm.globals, slot, name, vm.pop(), mutable).(*object.Error); ok {
+					return vm.raise(err, start)
+				}
+				continue
+			}
+
+			val := vm.pop()
+			evaluator.NameFunction(val, name)
+			vm.setLocal(frame, slot, val)
+
+		case code.OpGetAssignable:
+			kind := vm.readUint8(frame, ins)
+			slot := vm.readUint16(frame, ins)
+			name := vm.names[vm.readUint16(frame, ins)]
+
+			if kind == code.VarGlobal {
+				res := evaluator.Assignable(vm.globals, 0, slot, name)
+				if err, ok := res.(*object.Error); ok {
+					return vm.raise(err, start)
+				}
+				vm.push(res)
+				continue
+			}
+
+			val := vm.variable(frame, kind, slot)
+			if val == nil {
+				return vm.raise(evaluator.Undeclared(name), start)
+			}
+			vm.push(val)
+
+		case code.OpAssign:
+			kind := vm.readUint8(frame, ins)
+			slot := vm.readUint16(frame, ins)
+			name := vm.names[vm.readUint16(frame, ins)]
+
+			if kind == code.VarGlobal {
+				res := evaluator.Assign(vm.globals, 0, slot, name, vm.pop())
+				if err, ok := res.(*object.Error); ok {
+					return vm.raise(err, start)
+				}
+				vm.push(res)
+				continue
+			}
+
+			// OpGetAssignable checked the variable is bound, the value stays on the stack as the result
+			val := vm.stack[vm.sp-1]
+			evaluator.NameFunction(val, name)
+			if kind == code.VarLocal {
+				vm.setLocal(frame, slot, val)
+			} else {
+				frame.cl.Free[slot].Value = val
+			}
+
+		case code.OpNewCell:
+			local := &vm.stack[frame.base+vm.readUint16(frame, ins)]
+			*local = &object.Cell{Value: *local}
+
+		case code.OpClearLocals:
+			first := frame.base + vm.readUint16(frame, ins)
+			n := vm.readUint16(frame, ins)
+
+			for i := first; i < first+n; i++ {
+				vm.stack[i] = nil
+			}
+
+		case code.OpArray:
+			n := vm.readUint16(frame, ins)
+
+			elements := make([]object.Object, n)
+			copy(elements, vm.stack[vm.sp-n:vm.sp])
+			vm.sp -= n
+
+			vm.push(&object.Array{Elements: elements})
+
+		case code.OpCheckKey:
+			if _, err := evaluator.HashKey(vm.stack[vm.sp-1]); err != nil {
+				return vm.raise(err, start)
+			}
+
+		case code.OpHash:
+			n := vm.readUint16(frame, ins)
+
+			hash := object.NewHash()
+			for i := vm.sp - 2*n; i < vm.sp; i += 2 {
+				key, _ := evaluator.HashKey(vm.stack[i])
+				hash.Set(key, vm.stack[i+1])
+			}
+			vm.sp -= 2 * n
+
+			vm.push(hash)
+
+		case code.OpIndex:
+			index := vm.pop()
+			left := vm.pop()
+
+			res := evaluator.Index(left, index)
+			if err, ok := res.(*object.Error); ok {
+				return vm.raise(err, start)
+			}
+			vm.push(res)
+
+		case code.OpSetIndex:
+			val := vm.pop()
+			index := vm.pop()
+			left := vm.pop()
+
+			res := evaluator.SetIndex(left, index, val)
+			if err, ok := res.(*object.Error); ok {
+				return vm.raise(err, start)
+			}
+			vm.push(res)
+
+		case code.OpClosure:
+			fn := vm.constants[vm.readUint16(frame, ins)].(*object.CompiledFunction)
+
+			free := make([]*object.Cell, len(fn.Free))
+			for i, capture := range fn.Free {
+				if capture.Local {
+					free[i] = vm.stack[frame.base+capture.Index].(*object.Cell)
+				} else {
+					free[i] = frame.cl.Free[capture.Index]
+				}
+			}
+
+			vm.push(&object.Closure{Fn: fn, Free: free})
+
+		case code.OpCall:
+			n := int(ins[frame.ip])
+			frame.ip++
+
+			callee := vm.stack[vm.sp-1-n]
+
+			switch fn := callee.(type) {
+			case *object.Closure:
+				if n != len(fn.Fn.Parameters) {
+					return vm.raise(evaluator.WrongArity(n, len(fn.Fn.Parameters)), start)
+				}
+
+				if err := vm.budget.Enter(); err != nil {
+					return vm.raise(err, start)
+				}
+
+				span := frame.cl.Fn.Spans[start]
+				base := vm.sp - n
+				vm.enter(fn, base)
+
+				vm.frames = append(vm.frames, Frame{cl: fn, base: base, callSite: span.Start})
+				frame = &vm.frames[len(vm.frames)-1]
+				ins = fn.Fn.Instructions
+
+			case *object.Builtin:
+				// Builtins may keep their arguments, so they can't share the stack
+				args := make([]object.Object, n)
+				copy(args, vm.stack[vm.sp-n:vm.sp])
+				vm.sp -= n + 1
+
+				res := fn.Fn(vm.globals, args...)
+				if err, ok := res.(*object.Error); ok {
+					return vm.raise(err, start)
+				}
+				vm.push(res)
+
+			default:
+				return vm.raise(evaluator.NotAFunction(callee), start)
+			}
+
+		case code.OpReturnValue:
+			val := vm.pop()
+
+			if len(vm.frames) == 1 {
+				return val
+			}
+
+			vm.sp = frame.base - 1
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			vm.budget.Leave()
+			frame = &vm.frames[len(vm.frames)-1]
+			ins = frame.cl.Fn.Instructions
+
+			vm.push(val)
+
+		case code.OpIter:
+			it, err := evaluator.NewIterator(vm.pop())
+			if err != nil {
+				return vm.raise(err, start)
+			}
+			vm.push(it)
+
+		case code.OpIterNext:
+			target := vm.readUint16(frame, ins)
+
+			item, ok := vm.stack[vm.sp-1].(*object.Iterator).Next()
+			if ok {
+				vm.push(item)
+			} else {
+				frame.ip = target
+			}
+		}
+	}
+
+	if vm.sp == frame.cl.Fn.Locals {
+		return nil
+	}
+
+	return vm.stack[vm.sp-1]
+}
+
+// enter Set up the locals of a call to fn, which start at base with the arguments. Each argument moves to the slot
+// of its parameter, the same name given twice shares one, and every other local starts unbound
+func (vm *VM) enter(fn *object.Closure, base int) {
+	locals := fn.Fn.Locals
+
+	if base+locals > len(vm.stack) {
+		vm.stack = append(vm.stack, make([]object.Object, base+locals)...)
+	}
+
+	bound := 0
+	for i, param := range fn.Fn.Parameters {
+		vm.stack[base+param.Slot] = vm.stack[base+i]
+		if param.Slot >= bound {
+			bound = param.Slot + 1
+		}
+	}
+
+	for i := base + bound; i < base+locals; i++ {
+		vm.stack[i] = nil
+	}
+
+	vm.sp = base + locals
+}
+
+// variable The value of a local or free variable, nil while it is unbound
+func (vm *VM) variable(frame *Frame, kind int, slot int) object.Object {
+	if kind == code.VarFree {
+		return frame.cl.Free[slot].Value
+	}
+
+	val := vm.stack[frame.base+slot]
+	if cell, ok := val.(*object.Cell); ok {
+		return cell.Value
+	}
+
+	return val
+}
+
+// setLocal Bind the local at slot, through its cell when closures capture it
+func (vm *VM) setLocal(frame *Frame, slot int, val object.Object) {
+	local := &vm.stack[frame.base+slot]
+	if cell, ok := (*local).(*object.Cell); ok {
+		cell.Value = val
+		return
+	}
+
+	*local = val
+}
+
+// integerOperation Apply the operator of op to two integers without going through the evaluator, nil unless both
+// operands are integers and the result is one too or a comparison. Overflows, division by zero and powers are
+// left to evaluator.InfixOperator
+func integerOperation(op code.Opcode, left, right object.Object) object.Object {
+	l, ok := left.(*object.Integer)
+	if !ok {
+		return nil
+	}
+
+	r, ok := right.(*object.Integer)
+	if !ok {
+		return nil
+	}
+
+	a, b := l.Value, r.Value
+
+	switch op {
+	case code.OpAdd:
+		if sum := a + b; (sum > a) == (b > 0) {
+			return integer(sum)
+		}
+	case code.OpSub:
+		if difference := a - b; (difference < a) == (b > 0) {
+			return integer(difference)
+		}
+	case code.OpMul:
+		if b == 0 {
+			return integer(0)
+		}
+		if product := a * b; product/b == a && !(b == -1 && a == math.MinInt64) {
+			return integer(product)
+		}
+	case code.OpDiv:
+		if b != 0 && !(b == -1 && a == math.MinInt64) {
+			return integer(a / b)
+		}
+	case code.OpMod:
+		if b != 0 {
+			return integer(a % b)
+		}
+	case code.OpEqual:
+		return boolean(a == b)
+	case code.OpNotEqual:
+		return boolean(a != b)
+	case code.OpGreaterThan:
+		return boolean(a > b)
+	case code.OpLessThan:
+		return boolean(a < b)
+	case code.OpGreaterEqual:
+		return boolean(a >= b)
+	case code.OpLessEqual:
+		return boolean(a <= b)
+	}
+
+	return nil
+}
+
+// integer An integer object for v, shared when v is small
+func integer(v int64) *object.Integer {
+	if v >= minSmallInt && v <= maxSmallInt {
+		return smallInts[v-minSmallInt]
+	}
+
+	return &object.Integer{Value: v}
+}
+
+func boolean(b bool) *object.Boolean {
+	if b {
+		return evaluator.TRUE
+	}
+
+	return evaluator.FALSE
+}
+
+// raise Locate err at the instruction starting at offset in the current frame, then add a stack frame for
+// every call it unwinds through, innermost first
+func (vm *VM) raise(err *object.Error, offset int) object.Object {
+	frame := &vm.frames[len(vm.frames)-1]
+	if span, ok := frame.cl.Fn.Spans[offset]; ok && !err.Pos.IsValid() {
+		err.Pos = span.Start
+		err.End = span.End
+	}
+
+	for i := len(vm.frames) - 1; i > 0; i-- {
+		err.Trace = append(err.Trace, object.Frame{Function: vm.frames[i].cl.Name, Pos: vm.frames[i].callSite})
+	}
+
+	return err
+}
+
+func (vm *VM) readUint16(frame *Frame, ins code.Instructions) int {
+	operand := int(code.ReadUint16(ins[frame.ip:]))
+	frame.ip += 2
+
+	return operand
+}
+
+func (vm *VM) readUint8(frame *Frame, ins code.Instructions) int {
+	operand := int(ins[frame.ip])
+	frame.ip++
+
+	return operand
+}
+
+func (vm *VM) push(obj object.Object) {
+	if vm.sp == len(vm.stack) {
+		vm.stack = append(vm.stack, make([]object.Object, len(vm.stack))...)
+	}
+
+	vm.stack[vm.sp] = obj
+	vm.sp++
+}
+
+func (vm *VM) pop() object.Object {
+	vm.sp--
+	return vm.stack[vm.sp]
+}
@@ -0,0 +1,168 @@
+package vm
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/seailly/mi/compiler"
+	"github.com/seailly/mi/evaluator"
+	"github.com/seailly/mi/lexer"
+	"github.com/seailly/mi/object"
+	"github.com/seailly/mi/parser"
+	"github.com/seailly/mi/resolver"
+	"github.com/stretchr/testify/require"
+)
+
+func run(t *testing.T, input string) object.Object {
+	t.Helper()
+
+	return runContext(t, context.Background(), input, evaluator.Limits{})
+}
+
+func runContext(t *testing.T, ctx context.Context, input string, limits evaluator.Limits) object.Object {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors(), input)
+
+	env := object.NewEnvironment()
+	require.Empty(t, resolver.Resolve(program, env, evaluator.IsBuiltin), input)
+
+	c := compiler.New()
+	require.NoError(t, c.Compile(program), input)
+
+	return New(c.Bytecode(), env).RunContext(ctx, limits)
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + 2 * 3", "7"},
+		{"mut a = 1; a", "1"},
+		{"mut a = 1", "<nil>"},
+		{"if (1 > 2) { 10 }", "null"},
+		{"if (1 < 2) { 10 } else { 20 }", "10"},
+		{"mut add = fn(a, b) { a + b }; add(1, 2)", "3"},
+		{"mut fib = fn(n) { if (n < 2) { return n }; fib(n - 1) + fib(n - 2) }; fib(15)", "610"},
+		{"mut counter = fn() { mut n = 0; fn() { n += 1 } }; mut c = counter(); c(); c()", "2"},
+		{"mut a = [1, 2, 3]; a[1] *= 10; a", "[1, 20, 3]"},
+		{`mut h = {"a": 1}; h["b"] = 2; h["a"] + h["b"]`, "3"},
+		{"mut sum = 0; for (x in range(1, 11)) { if (x % 2 == 0) { continue }; sum += x } sum", "25"},
+		{"mut i = 0; while (true) { i += 1; if (i == 5) { break } } i", "5"},
+		{"mut fns = []; for (x in [1, 2]) { fns = push(fns, fn() { x }) } fns[0]() + fns[1]()", "3"},
+		{"for (x in [1, 2, 3]) { if (x == 2) { return x * 100 } }", "200"},
+		{"mut f = fn() { for (x in [1, 2, 3]) { if (x == 2) { return [x, 1] } } }; f()", "[2, 1]"},
+		{"mut i = 0; for (x in range(1, 4)) { i += [x, if (x == 2) { break }][0] } i", "1"},
+		{"mut i = 0; while (i < 3) { i = i + [1, if (i == 1) { i += 1; continue }][0] } i", "3"},
+		{"mut f = fn() { mut a = 1; mut g = fn() { a += 1 }; g(); a }; f()", "2"},
+		{"mut f = fn() { mut n = 0; mut g = fn() { fn() { n += 1 } }; mut h = g(); h(); h(); n }; f()", "2"},
+		{"mut f = fn(n) { mut even = fn(n) { if (n == 0) { true } else { odd(n - 1) } }; " +
+			"mut odd = fn(n) { if (n == 0) { false } else { even(n - 1) } }; even(n) }; f(10)", "true"},
+		{"mut f = fn() { mut fs = []; for (x in [1, 2]) { mut y = x * 10; fs = push(fs, fn() { y += x; y }) }; " +
+			"fs[0]() + fs[1]() + fs[0]() }; f()", "45"},
+		{"mut f = fn() { for (x in [1, 2]) { if (x == 1) { mut y = x }; y } }; f()", "ERROR: 1:63: identifier not found: y"},
+		{"mut f = fn(a, a) { if (false) { mut b = 1 }; b }; f(1, 2)", "ERROR: 1:46: identifier not found: b"},
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"3037000500 * 3037000500", "9223372037000250000"},
+		{"7 / 2 + -7 % 3", "2"},
+		{"1 / 0", "ERROR: 1:3: division by zero"},
+		{"mut f = fn() { 1 + true }; f()", "ERROR: 1:18: type mismatch: INTEGER + BOOLEAN"},
+		{"len(1)", "ERROR: 1:1: argument to len not supported, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, inspect(run(t, tt.input)), tt.input)
+	}
+}
+
+func TestRunImmutableAcrossPrograms(t *testing.T) {
+	env := object.NewEnvironment()
+
+	var result object.Object
+
+	for _, line := range []string{"let a = 1;", "mut a = 2;"} {
+		program := parser.New(lexer.New(line)).ParseProgram()
+		require.Empty(t, resolver.Resolve(program, env, evaluator.IsBuiltin), line)
+
+		c := compiler.New()
+		require.NoError(t, c.Compile(program), line)
+
+		result = New(c.Bytecode(), env).Run()
+	}
+
+	require.Equal(t, "ERROR: 1:5: cannot redeclare immutable binding: a", inspect(result))
+}
+
+func TestRunOutput(t *testing.T) {
+	var out bytes.Buffer
+
+	p := parser.New(lexer.New(`mut f = fn(x) { puts("x", x) }; for (x in [1, 2]) { f(x) }`))
+	program := p.ParseProgram()
+
+	env := object.NewEnvironment()
+	env.SetOutput(&out)
+	require.Empty(t, resolver.Resolve(program, env, evaluator.IsBuiltin))
+
+	c := compiler.New()
+	require.NoError(t, c.Compile(program))
+
+	New(c.Bytecode(), env).Run()
+	require.Equal(t, "x 1\nx 2\n", out.String())
+}
+
+func TestStackTrace(t *testing.T) {
+	input := `mut inner = fn() { 1 / 0 };
+mut outer = fn() { inner() };
+outer()`
+
+	err, ok := run(t, input).(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "ERROR: 1:22: division by zero", err.Inspect())
+	require.Equal(t, []object.Frame{
+		{Function: "inner", Pos: err.Trace[0].Pos},
+		{Function: "outer", Pos: err.Trace[1].Pos},
+	}, err.Trace)
+	require.Equal(t, "2:20", err.Trace[0].Pos.String())
+	require.Equal(t, "3:1", err.Trace[1].Pos.String())
+}
+
+func TestDeepRecursion(t *testing.T) {
+	input := "mut count = fn(n) { if (n == 0) { return 0 }; 1 + count(n - 1) }; count(5000)"
+
+	require.Equal(t, "5000", inspect(run(t, input)))
+}
+
+func TestRunContextLimits(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		input  string
+		ctx    context.Context
+		limits evaluator.Limits
+		cause  error
+	}{
+		{"while (true) { }", context.Background(), evaluator.Limits{MaxSteps: 1000}, evaluator.ErrStepLimit},
+		{"while (true) { }", context.Background(), evaluator.Limits{Timeout: time.Millisecond}, context.DeadlineExceeded},
+		{"1", canceled, evaluator.Limits{}, context.Canceled},
+		{"fn(f) { f(f) }(fn(f) { f(f) })", context.Background(), evaluator.Limits{}, evaluator.ErrDepthLimit},
+		{"mut f = fn(n) { f(n + 1) }; f(0)", context.Background(), evaluator.Limits{MaxDepth: 50}, evaluator.ErrDepthLimit},
+	}
+
+	for _, tt := range tests {
+		err, ok := runContext(t, tt.ctx, tt.input, tt.limits).(*object.Error)
+		require.True(t, ok, tt.input)
+		require.ErrorIs(t, err.Cause, tt.cause, tt.input)
+		require.Equal(t, tt.cause.Error(), err.Message, tt.input)
+	}
+
+	err := runContext(t, context.Background(), "fn(f) { f(f) }(fn(f) { f(f) })", evaluator.Limits{}).(*object.Error)
+	require.Equal(t, "1:24", err.Pos.String())
+	require.Len(t, err.Trace, evaluator.DefaultMaxDepth)
+}